@@ -0,0 +1,562 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/mmmorris1975/aws-config/config"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// attribute is the output representation of a single profile attribute
+type attribute struct {
+	Name   string `json:"name" yaml:"name"`
+	Value  string `json:"value" yaml:"value"`
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+}
+
+// attrFlag collects repeated -attr name=glob flags
+type attrFlag map[string]string
+
+func (f attrFlag) String() string {
+	s := make([]string, 0, len(f))
+	for k, v := range f {
+		s = append(s, fmt.Sprintf("%s=%s", k, v))
+	}
+	return strings.Join(s, ",")
+}
+
+func (f attrFlag) Set(v string) error {
+	p := strings.SplitN(v, "=", 2)
+	if len(p[0]) < 1 {
+		return fmt.Errorf("invalid attribute filter '%s'", v)
+	}
+
+	if len(p) < 2 {
+		p = append(p, "")
+	}
+	f[p[0]] = p[1]
+	return nil
+}
+
+func runList(a *app, args []string) error {
+	q := &config.ProfileQuery{Attributes: make(attrFlag)}
+
+	var roles bool
+	var kind string
+
+	fs := a.newFlagSet("list")
+	fs.BoolVar(&roles, "roles", false, "only list profiles with a role_arn attribute")
+	fs.StringVar(&q.Name, "name", "", "only list profiles with a name matching this glob pattern")
+	fs.StringVar(&kind, "kind", "", "only list profiles of this kind (role, sso, static, process)")
+	fs.Var(attrFlag(q.Attributes), "attr", "only list profiles with an attribute matching name=glob (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	q.Kind = config.ProfileKind(kind)
+	if roles {
+		q.Attributes["role_arn"] = ""
+	}
+
+	p, err := config.NewIniConfigProvider(source(a.configSource), a.options()...)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	entries := p.QueryProfiles(q)
+	return a.write(entries, func(w io.Writer) {
+		row(w, "NAME", "KIND", "SECTION", "SOURCE")
+		for _, e := range entries {
+			row(w, e.Name, e.Kind, e.Section, e.Source)
+		}
+	})
+}
+
+func runShow(a *app, args []string) error {
+	fs := a.newFlagSet("show")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	r, err := config.NewAwsConfigResolver(source(a.configSource), a.options()...)
+	if err != nil {
+		return err
+	}
+
+	c, err := r.Resolve(fs.Args()...)
+	if err != nil {
+		return err
+	}
+
+	attrs := make([]attribute, 0)
+	for k, v := range c.Attributes() {
+		attrs = append(attrs, attribute{Name: k, Value: v, Source: c.Source(k)})
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].Name < attrs[j].Name
+	})
+
+	data := struct {
+		Profile    string      `json:"profile" yaml:"profile"`
+		Attributes []attribute `json:"attributes" yaml:"attributes"`
+	}{c.Profile, attrs}
+
+	return a.write(data, func(w io.Writer) {
+		row(w, "ATTRIBUTE", "VALUE", "SOURCE")
+		for _, x := range attrs {
+			row(w, x.Name, x.Value, x.Source)
+		}
+	})
+}
+
+func runGet(a *app, args []string) error {
+	fs := a.newFlagSet("get")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errSilent
+	}
+
+	p, err := config.NewIniConfigProvider(source(a.configSource), a.options()...)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	c, err := p.Config(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	v, ok := c.Attributes()[fs.Arg(1)]
+	if !ok {
+		return fmt.Errorf("attribute '%s' not set in profile '%s'", fs.Arg(1), fs.Arg(0))
+	}
+
+	return a.write(attribute{Name: fs.Arg(1), Value: v, Source: c.Profile}, func(w io.Writer) {
+		row(w, v)
+	})
+}
+
+func runSet(a *app, args []string) error {
+	fs := a.newFlagSet("set")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 3 {
+		fs.Usage()
+		return errSilent
+	}
+
+	return a.updateConfig(func(p *config.IniConfigProvider) error {
+		return p.SetAttribute(fs.Arg(0), fs.Arg(1), fs.Arg(2))
+	})
+}
+
+func runUnset(a *app, args []string) error {
+	fs := a.newFlagSet("unset")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errSilent
+	}
+
+	return a.updateConfig(func(p *config.IniConfigProvider) error {
+		return p.UnsetAttribute(fs.Arg(0), fs.Arg(1))
+	})
+}
+
+func runLint(a *app, args []string) error {
+	fs := a.newFlagSet("lint")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cp, err := config.NewIniConfigProvider(source(a.configSource), a.options()...)
+	if err != nil {
+		return err
+	}
+	defer cp.Close()
+
+	cr, err := config.NewIniCredentialProvider(source(a.credentialsSource), a.options()...)
+	if err != nil {
+		return err
+	}
+	defer cr.Close()
+
+	diags := append(cp.Lint(cr), cr.Lint()...)
+	err = a.write(diags, func(w io.Writer) {
+		for _, d := range diags {
+			fmt.Fprintln(w, d)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, d := range diags {
+		if d.Severity == config.SeverityError {
+			return errSilent
+		}
+	}
+	return nil
+}
+
+func runKeyAge(a *app, args []string) error {
+	var maxAge int
+	var requireCreated bool
+
+	fs := a.newFlagSet("key-age")
+	fs.IntVar(&maxAge, "max-age", int(config.DefaultMaxKeyAge.Hours()/24), "maximum access key age, in days")
+	fs.BoolVar(&requireCreated, "require-created", false, "keys without a creation time are a policy violation")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cr, err := config.NewIniCredentialProvider(source(a.credentialsSource), a.options()...)
+	if err != nil {
+		return err
+	}
+	defer cr.Close()
+
+	policy := config.NewKeyAgePolicy().WithMaxAge(time.Duration(maxAge) * 24 * time.Hour).WithRequireCreated(requireCreated)
+	ages := cr.KeyAges(policy)
+
+	err = a.write(ages, func(w io.Writer) {
+		row(w, "PROFILE", "ACCESS KEY", "CREATED", "AGE", "VIOLATION")
+		for _, k := range ages {
+			created, age := "-", "-"
+			if k.Created != nil {
+				created = k.Created.Format(time.RFC3339)
+				age = fmt.Sprintf("%dd", k.Age)
+			}
+			row(w, k.Profile, k.AccessKeyId, created, age, k.Violation)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range ages {
+		if len(k.Violation) > 0 {
+			return errSilent
+		}
+	}
+	return nil
+}
+
+func runExport(a *app, args []string) error {
+	var shell string
+	var unset, noCreds bool
+
+	fs := a.newFlagSet("export")
+	fs.StringVar(&shell, "shell", string(config.ShellBash), "shell syntax: bash, zsh, fish, powershell, or dotenv")
+	fs.BoolVar(&unset, "unset", false, "unset environment variables which conflict with the exported values")
+	fs.BoolVar(&noCreds, "no-credentials", false, "do not export credentials from the credentials file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	r, err := config.NewAwsConfigResolver(source(a.configSource), a.options()...)
+	if err != nil {
+		return err
+	}
+
+	// profiles only found in the credentials file export the credentials, without configuration
+	c, err := r.Resolve(fs.Args()...)
+	var nf *config.ProfileNotFoundError
+	credsOnly := !noCreds && errors.As(err, &nf) && (fs.NArg() < 1 || nf.Profile == fs.Arg(0))
+	if credsOnly {
+		c = &config.AwsConfig{Profile: nf.Profile}
+	} else if err != nil {
+		return err
+	}
+
+	var creds *credentials.Value
+	if !noCreds {
+		p, err := config.NewIniCredentialProvider(source(a.credentialsSource), a.options()...)
+		if err != nil {
+			return err
+		}
+		defer p.Close()
+
+		// profiles without credentials, and role profiles, only export the configuration
+		v, _, err := config.ResolveCredentials(r, p, c.Profile)
+		switch {
+		case err == nil:
+			creds = &v
+		case credsOnly, !errors.Is(err, config.ErrProfileNotFound) && !errors.Is(err, config.ErrRoleProfile):
+			return err
+		}
+	}
+
+	return config.NewEnvExporter(config.ShellFormat(shell)).WithUnset(unset).Export(a.out, c, creds)
+}
+
+func runCredentialProcess(a *app, args []string) error {
+	fs := a.newFlagSet("credential-process")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	r, err := config.NewAwsConfigResolver(source(a.configSource), a.options()...)
+	if err != nil {
+		return err
+	}
+
+	p, err := config.NewIniCredentialProvider(source(a.credentialsSource), a.options()...)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	return config.EmitCredentialProcess(a.out, r, p, fs.Args()...)
+}
+
+func runMigrateCredentials(a *app, args []string) error {
+	fs := a.newFlagSet("migrate-credentials")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cp, err := config.NewIniConfigProvider(source(a.configSource), a.options()...)
+	if err != nil {
+		return err
+	}
+	defer cp.Close()
+
+	cr, err := config.NewIniCredentialProvider(source(a.credentialsSource), a.options()...)
+	if err != nil {
+		return err
+	}
+	defer cr.Close()
+
+	if !cp.Writable() || !cr.Writable() {
+		return fmt.Errorf("config and credentials sources must be writable files")
+	}
+
+	migrated, err := cp.MigrateCredentials(cr, fs.Args()...)
+	if err != nil {
+		return err
+	}
+
+	if len(migrated) > 0 {
+		// save the credentials first, so a failure can't lose the only copy of the credentials
+		if err := cr.SaveTo(cr.Path); err != nil {
+			return err
+		}
+
+		if err := cp.SaveTo(cp.Path); err != nil {
+			return err
+		}
+	}
+
+	return a.write(migrated, func(w io.Writer) {
+		for _, n := range migrated {
+			row(w, n)
+		}
+	})
+}
+
+// options returns the config package options for the global flags
+func (a *app) options() []config.Option {
+	opts := make([]config.Option, 0)
+	if len(a.keyFile) > 0 {
+		opts = append(opts, config.WithKeyFile(a.keyFile))
+	}
+	return opts
+}
+
+// runGenerate writes a role profile to the config file for each account in the account list, or prints the changes
+// as a diff of the profile sections with the -dry-run flag
+func runGenerate(a *app, args []string) error {
+	var role, template, nameFormat, accountsFormat string
+	var inherit, dryRun bool
+
+	fs := a.newFlagSet("generate")
+	fs.StringVar(&role, "role", "", "name of the role to assume in each account")
+	fs.StringVar(&template, "template", "", "profile providing the attributes of the generated profiles")
+	fs.StringVar(&nameFormat, "name-format", "${account_name}", "format of the generated profile names")
+	fs.StringVar(&accountsFormat, "accounts-format", "", "account list format: csv or json (default from file extension)")
+	fs.BoolVar(&inherit, "inherit", false, "inherit the template profile, instead of copying its attributes")
+	fs.BoolVar(&dryRun, "dry-run", false, "print the changes without updating the config file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errSilent
+	}
+
+	accounts, err := readAccounts(fs.Arg(0), accountsFormat)
+	if err != nil {
+		return err
+	}
+
+	cp, err := config.NewIniConfigProvider(source(a.configSource), a.options()...)
+	if err != nil {
+		return err
+	}
+	defer cp.Close()
+
+	g := config.NewProfileGenerator(role, template).WithNameFormat(nameFormat).WithInherit(inherit)
+	changes, err := g.Plan(cp, accounts)
+	if err != nil {
+		return err
+	}
+
+	if !dryRun {
+		if !cp.Writable() {
+			return fmt.Errorf("config source is not a writable file")
+		}
+
+		if err := g.Apply(cp, changes); err != nil {
+			return err
+		}
+
+		if err := cp.SaveTo(cp.Path); err != nil {
+			return err
+		}
+	}
+
+	return a.write(changes, func(w io.Writer) {
+		if dryRun {
+			for _, c := range changes {
+				fmt.Fprint(w, c.Diff())
+			}
+			return
+		}
+
+		for _, c := range changes {
+			row(w, c.Profile, c.AccountId, c.Action)
+		}
+	})
+}
+
+// readAccounts reads the account list from the named file, or stdin if the name is '-'.  If no name is given, the
+// accounts are listed using the AWS Organizations API.
+func readAccounts(name, format string) ([]config.Account, error) {
+	if len(name) < 1 {
+		s, err := session.NewSessionWithOptions(session.Options{SharedConfigState: session.SharedConfigEnable})
+		if err != nil {
+			return nil, err
+		}
+		return config.OrganizationsAccounts(organizations.New(s))
+	}
+
+	r := io.Reader(os.Stdin)
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if len(format) < 1 {
+		format = "json"
+		if strings.EqualFold(filepath.Ext(name), ".csv") {
+			format = "csv"
+		}
+	}
+
+	switch format {
+	case "csv":
+		return config.ReadAccountsCsv(r)
+	case "json":
+		return config.ReadAccountsJson(r)
+	}
+	return nil, fmt.Errorf("invalid account list format '%s'", format)
+}
+
+// updateConfig loads the config file, applies the update function, and saves the result back to the file
+func (a *app) updateConfig(f func(p *config.IniConfigProvider) error) error {
+	p, err := config.NewIniConfigProvider(source(a.configSource), a.options()...)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	if !p.Writable() {
+		return fmt.Errorf("config source is not a writable file")
+	}
+
+	if err := f(p); err != nil {
+		return err
+	}
+	return p.SaveTo(p.Path)
+}
+
+func runEncrypt(a *app, args []string) error {
+	fs := a.newFlagSet("encrypt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if len(a.keyFile) < 1 {
+		return fmt.Errorf("the -key-file flag is required")
+	}
+
+	key, err := ioutil.ReadFile(a.keyFile)
+	if err != nil {
+		return err
+	}
+
+	return a.updateCredentials(func(p *config.IniCredentialProvider) error {
+		return p.Encrypt(bytes.TrimRight(key, "\r\n"))
+	})
+}
+
+func runDecrypt(a *app, args []string) error {
+	fs := a.newFlagSet("decrypt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return a.updateCredentials(func(p *config.IniCredentialProvider) error {
+		if !p.Encrypted() {
+			return fmt.Errorf("credentials file is not encrypted")
+		}
+
+		p.Decrypt()
+		return nil
+	})
+}
+
+// updateCredentials loads the credentials file, applies the update function, and saves the result back to the file
+func (a *app) updateCredentials(f func(p *config.IniCredentialProvider) error) error {
+	p, err := config.NewIniCredentialProvider(source(a.credentialsSource), a.options()...)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	if !p.Writable() {
+		return fmt.Errorf("credentials source is not a writable file")
+	}
+
+	if err := f(p); err != nil {
+		return err
+	}
+	return p.SaveTo(p.Path)
+}
@@ -0,0 +1,157 @@
+// Command aws-config is a tool for inspecting and editing the profiles in the AWS cli/sdk config and credentials files.
+//
+// Usage:
+//
+//	aws-config [-config file] [-credentials file] [-output table|json|yaml] <command> [args]
+//
+// The commands are:
+//
+//	list    list profiles, optionally filtered by name, kind, or attribute values
+//	show    show the resolved configuration for a profile, and the profile providing each attribute
+//	get     print the value of a profile attribute
+//	set     set the value of a profile attribute
+//	unset   remove an attribute from a profile
+//	lint    check the config and credentials files for problems
+//	export  print shell commands to export the resolved profile and credentials as environment variables
+//	credential-process
+//	        print the profile credentials as credential_process JSON output
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// errSilent is returned by commands which have already reported the reason for their failure
+var errSilent = errors.New("")
+
+type command struct {
+	run   func(a *app, args []string) error
+	usage string
+}
+
+var commands map[string]command
+
+// commands are registered in init(), since the command functions refer back to the map for their usage text
+func init() {
+	commands = map[string]command{
+		"list":  {runList, "list [-roles] [-name glob] [-kind kind] [-attr name=glob ...]"},
+		"show":  {runShow, "show [profile]"},
+		"get":   {runGet, "get <profile> <attribute>"},
+		"set":   {runSet, "set <profile> <attribute> <value>"},
+		"unset": {runUnset, "unset <profile> <attribute>"},
+		"lint":  {runLint, "lint"},
+		"export": {runExport,
+			"export [-shell bash|zsh|fish|powershell|dotenv] [-unset] [-no-credentials] [profile]"},
+		"credential-process":  {runCredentialProcess, "credential-process [profile]"},
+		"key-age":             {runKeyAge, "key-age [-max-age days] [-require-created]"},
+		"encrypt":             {runEncrypt, "encrypt"},
+		"decrypt":             {runDecrypt, "decrypt"},
+		"migrate-credentials": {runMigrateCredentials, "migrate-credentials [profile ...]"},
+		"generate": {runGenerate, "generate [-role name] [-template profile] [-name-format format] " +
+			"[-accounts-format csv|json] [-inherit] [-dry-run] [accounts-file|-]"},
+	}
+}
+
+type app struct {
+	configSource      string
+	credentialsSource string
+	format            string
+	keyFile           string
+	out               io.Writer
+	err               io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, out, errOut io.Writer) int {
+	a := &app{out: out, err: errOut}
+
+	fs := flag.NewFlagSet("aws-config", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+	fs.StringVar(&a.configSource, "config", "", "config file path or url (default: AWS_CONFIG_FILE or ~/.aws/config)")
+	fs.StringVar(&a.credentialsSource, "credentials", "",
+		"credentials file path or url (default: AWS_SHARED_CREDENTIALS_FILE or ~/.aws/credentials)")
+	fs.StringVar(&a.format, "output", formatTable, "output format: table, json, or yaml")
+	fs.StringVar(&a.keyFile, "key-file", "", "file containing the passphrase of an encrypted credentials file")
+	fs.Usage = func() { usage(fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if !isFormat(a.format) {
+		fmt.Fprintf(errOut, "invalid output format: %s\n", a.format)
+		return 2
+	}
+
+	if fs.NArg() < 1 {
+		usage(fs)
+		return 2
+	}
+
+	c, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(errOut, "unknown command: %s\n", fs.Arg(0))
+		usage(fs)
+		return 2
+	}
+
+	if err := c.run(a, fs.Args()[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return 2
+		}
+
+		if err != errSilent {
+			fmt.Fprintf(errOut, "%s: %v\n", fs.Arg(0), err)
+		}
+		return 1
+	}
+	return 0
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintln(w, "Usage: aws-config [flags] <command> [args]")
+	fmt.Fprintln(w, "\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for k := range commands {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		fmt.Fprintf(w, "  %s\n", commands[n].usage)
+	}
+
+	fmt.Fprintln(w, "\nFlags:")
+	fs.PrintDefaults()
+}
+
+// newFlagSet creates the flag set for a command, with output sent to the app's error writer
+func (a *app) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.err)
+	fs.Usage = func() {
+		fmt.Fprintf(a.err, "Usage: aws-config %s\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// source converts the string source to the form expected by the config package constructors, where
+// a nil source means to use the default file location
+func source(s string) interface{} {
+	if len(strings.TrimSpace(s)) < 1 {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	confFile  = "../../config/.aws_config"
+	credsFile = "../../config/.aws_credentials"
+)
+
+func runCmd(args ...string) (int, string, string) {
+	out := new(bytes.Buffer)
+	errOut := new(bytes.Buffer)
+	rc := run(args, out, errOut)
+	return rc, out.String(), errOut.String()
+}
+
+func tempConfig(t *testing.T) string {
+	b, err := ioutil.ReadFile(confFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ioutil.TempFile("", "aws-config-cmd-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestRun(t *testing.T) {
+	t.Run("no command", func(t *testing.T) {
+		if rc, _, _ := runCmd(); rc != 2 {
+			t.Errorf("unexpected return code %d", rc)
+		}
+	})
+
+	t.Run("bad command", func(t *testing.T) {
+		if rc, _, e := runCmd("nope"); rc != 2 || !strings.Contains(e, "unknown command") {
+			t.Errorf("unexpected return code %d", rc)
+		}
+	})
+
+	t.Run("bad format", func(t *testing.T) {
+		if rc, _, _ := runCmd("-output", "xml", "list"); rc != 2 {
+			t.Errorf("unexpected return code %d", rc)
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	t.Run("table", func(t *testing.T) {
+		rc, out, _ := runCmd("-config", confFile, "list")
+		if rc != 0 || !strings.HasPrefix(out, "NAME") || !strings.Contains(out, "uncommon") {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+
+	t.Run("json roles", func(t *testing.T) {
+		rc, out, _ := runCmd("-config", confFile, "-output", "json", "list", "-roles")
+		if rc != 0 {
+			t.Errorf("unexpected return code %d", rc)
+			return
+		}
+
+		e := make([]map[string]string, 0)
+		if err := json.Unmarshal([]byte(out), &e); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(e) != 1 || e[0]["name"] != "mfa" || e[0]["kind"] != "role" {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+
+	t.Run("yaml attr", func(t *testing.T) {
+		rc, out, _ := runCmd("-config", confFile, "-output", "yaml", "list", "-attr", "region=us-*")
+		if rc != 0 {
+			t.Errorf("unexpected return code %d", rc)
+			return
+		}
+
+		e := make([]map[string]string, 0)
+		if err := yaml.Unmarshal([]byte(out), &e); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(e) != 2 {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+}
+
+func TestShow(t *testing.T) {
+	rc, out, _ := runCmd("-config", confFile, "-output", "json", "show", "mfa")
+	if rc != 0 {
+		t.Errorf("unexpected return code %d", rc)
+		return
+	}
+
+	data := struct {
+		Profile    string
+		Attributes []attribute
+	}{}
+	if err := json.Unmarshal([]byte(out), &data); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if data.Profile != "mfa" || len(data.Attributes) != 5 {
+		t.Errorf("unexpected output: %s", out)
+		return
+	}
+
+	for _, a := range data.Attributes {
+		if a.Name == "mfa_serial" && a.Source != "default" {
+			t.Error("bad attribute source")
+		}
+	}
+}
+
+func TestGetSetUnset(t *testing.T) {
+	f := tempConfig(t)
+	defer os.Remove(f)
+
+	if rc, out, _ := runCmd("-config", f, "get", "other", "custom_attribute"); rc != 0 || out != "whatIsIt\n" {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	if rc, _, _ := runCmd("-config", f, "set", "other", "custom_attribute", "changed"); rc != 0 {
+		t.Errorf("unexpected return code %d", rc)
+	}
+
+	if rc, out, _ := runCmd("-config", f, "get", "other", "custom_attribute"); rc != 0 || out != "changed\n" {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	if rc, _, _ := runCmd("-config", f, "unset", "other", "custom_attribute"); rc != 0 {
+		t.Errorf("unexpected return code %d", rc)
+	}
+
+	if rc, _, e := runCmd("-config", f, "get", "other", "custom_attribute"); rc != 1 || len(e) < 1 {
+		t.Errorf("unexpected return code %d", rc)
+	}
+
+	if rc, _, _ := runCmd("-config", f, "set", "other"); rc != 1 {
+		t.Errorf("unexpected return code %d", rc)
+	}
+}
+
+func TestLint(t *testing.T) {
+	rc, out, _ := runCmd("-config", confFile, "-credentials", credsFile, "lint")
+	if rc != 1 || !strings.Contains(out, "role_arn") || !strings.Contains(out, "no-secret") {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestMigrateCredentials(t *testing.T) {
+	d, err := ioutil.TempDir("", "aws-config-cmd-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	conf, creds := filepath.Join(d, "config"), filepath.Join(d, "credentials")
+	if err := ioutil.WriteFile(conf, []byte("[default]\n\n[profile p]\nregion = us-east-1\naws_access_key_id = AKIAMOCK\naws_secret_access_key = MockSecret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(creds, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if rc, out, e := runCmd("-config", conf, "-credentials", creds, "migrate-credentials"); rc != 0 || out != "p\n" {
+		t.Errorf("unexpected result: rc=%d out=%s err=%s", rc, out, e)
+	}
+
+	if rc, out, _ := runCmd("-config", conf, "-credentials", creds, "lint"); rc != 0 || len(out) > 0 {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	if rc, out, _ := runCmd("-config", conf, "-credentials", creds, "export", "p"); rc != 0 || !strings.Contains(out, "AKIAMOCK") {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	d, err := ioutil.TempDir("", "aws-config-cmd-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	conf, accounts := filepath.Join(d, "config"), filepath.Join(d, "accounts.csv")
+	data := []byte("[default]\nregion = us-east-1\n\n[profile template]\nsource_profile = default\n")
+	if err := ioutil.WriteFile(conf, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(accounts, []byte("Id,Name,Status\n111111111111,Prod,ACTIVE\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		rc, out, e := runCmd("-config", conf, "generate", "-role", "Admin", "-template", "template", "-dry-run", accounts)
+		if rc != 0 || !strings.HasPrefix(out, "+[profile prod]\n+role_arn = arn:aws:iam::111111111111:role/Admin\n") {
+			t.Errorf("unexpected result: rc=%d out=%s err=%s", rc, out, e)
+		}
+
+		if b, _ := ioutil.ReadFile(conf); !bytes.Equal(b, data) {
+			t.Errorf("config file updated by dry run:\n%s", b)
+		}
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		for _, action := range []string{"added", "unchanged"} {
+			rc, out, e := runCmd("-config", conf, "generate", "-role", "Admin", "-template", "template", accounts)
+			if rc != 0 || !strings.Contains(out, action) {
+				t.Errorf("unexpected result: rc=%d out=%s err=%s", rc, out, e)
+			}
+		}
+
+		if rc, out, _ := runCmd("-config", conf, "get", "prod", "role_arn"); rc != 0 || out != "arn:aws:iam::111111111111:role/Admin\n" {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+
+	t.Run("bad format", func(t *testing.T) {
+		if rc, _, _ := runCmd("-config", conf, "generate", "-role", "Admin", "-accounts-format", "xml", accounts); rc == 0 {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestKeyAge(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		rc, out, _ := runCmd("-credentials", credsFile, "key-age")
+		if rc != 0 || !strings.Contains(out, "AKIA0THER") || strings.Contains(out, "ASIA") {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+
+	t.Run("require created", func(t *testing.T) {
+		rc, out, _ := runCmd("-credentials", credsFile, "-output", "json", "key-age", "-require-created")
+		if rc != 1 || !strings.Contains(out, `"violation": "access key creation time unknown"`) {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+}
+
+func TestEncrypt(t *testing.T) {
+	b, err := ioutil.ReadFile(credsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := ioutil.TempDir("", "aws-config-cmd-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	creds, key := filepath.Join(d, "credentials"), filepath.Join(d, "key")
+	if err := ioutil.WriteFile(creds, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(key, []byte("passphrase\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if rc, _, e := runCmd("-credentials", creds, "encrypt"); rc != 1 || !strings.Contains(e, "-key-file") {
+		t.Errorf("unexpected return code %d", rc)
+	}
+
+	if rc, _, e := runCmd("-credentials", creds, "-key-file", key, "encrypt"); rc != 0 {
+		t.Errorf("unexpected return code %d: %s", rc, e)
+	}
+
+	if rc, _, _ := runCmd("-credentials", creds, "key-age"); rc != 1 {
+		t.Errorf("unexpected return code %d", rc)
+	}
+
+	if rc, out, _ := runCmd("-credentials", creds, "-key-file", key, "key-age"); rc != 0 || !strings.Contains(out, "AKIA0THER") {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	if rc, _, e := runCmd("-credentials", creds, "-key-file", key, "decrypt"); rc != 0 {
+		t.Errorf("unexpected return code %d: %s", rc, e)
+	}
+
+	if rc, _, _ := runCmd("-credentials", creds, "key-age"); rc != 0 {
+		t.Errorf("unexpected return code %d", rc)
+	}
+}
+
+func TestExport(t *testing.T) {
+	t.Run("with credentials", func(t *testing.T) {
+		rc, out, _ := runCmd("-config", confFile, "-credentials", credsFile, "export", "other")
+		if rc != 0 || !strings.Contains(out, "export AWS_ACCESS_KEY_ID='AKIA0THER'") ||
+			!strings.Contains(out, "export AWS_REGION='us-west-1'") {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		rc, out, _ := runCmd("-config", confFile, "-credentials", credsFile, "export", "-shell", "fish", "-no-credentials", "other")
+		if rc != 0 || strings.Contains(out, "AWS_ACCESS_KEY_ID") || !strings.Contains(out, "set -gx AWS_PROFILE 'other'") {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+
+	t.Run("config only", func(t *testing.T) {
+		rc, out, _ := runCmd("-config", confFile, "-credentials", credsFile, "export", "mfa")
+		if rc != 0 || strings.Contains(out, "AWS_ACCESS_KEY_ID") || !strings.Contains(out, "export AWS_PROFILE='mfa'") {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+
+	t.Run("credentials only", func(t *testing.T) {
+		rc, out, _ := runCmd("-config", confFile, "-credentials", credsFile, "export", "token")
+		if rc != 0 || !strings.Contains(out, "export AWS_ACCESS_KEY_ID='accesskey'") || !strings.Contains(out, "export AWS_PROFILE='token'") {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+
+	t.Run("missing profile", func(t *testing.T) {
+		if rc, _, _ := runCmd("-config", confFile, "-credentials", credsFile, "export", "not-a-profile"); rc != 1 {
+			t.Errorf("unexpected return code %d", rc)
+		}
+	})
+
+	t.Run("incomplete credentials", func(t *testing.T) {
+		if rc, _, e := runCmd("-config", confFile, "-credentials", credsFile, "export", "empty"); rc != 1 || len(e) < 1 {
+			t.Errorf("unexpected return code %d", rc)
+		}
+	})
+
+	t.Run("bad shell", func(t *testing.T) {
+		if rc, _, _ := runCmd("-config", confFile, "-credentials", credsFile, "export", "-shell", "csh"); rc != 1 {
+			t.Errorf("unexpected return code %d", rc)
+		}
+	})
+}
+
+func TestCredentialProcess(t *testing.T) {
+	t.Run("good", func(t *testing.T) {
+		rc, out, _ := runCmd("-config", confFile, "-credentials", credsFile, "credential-process", "other")
+		if rc != 0 || out != `{"Version":1,"AccessKeyId":"AKIA0THER","SecretAccessKey":"0th3rSecr3T"}`+"\n" {
+			t.Errorf("unexpected output: %s", out)
+		}
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		rc, out, e := runCmd("-config", confFile, "-credentials", credsFile, "credential-process", "empty")
+		if rc == 0 || len(out) > 0 || len(e) < 1 {
+			t.Errorf("unexpected result: rc=%d out=%s err=%s", rc, out, e)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJson  = "json"
+	formatYaml  = "yaml"
+)
+
+func isFormat(f string) bool {
+	switch f {
+	case formatTable, formatJson, formatYaml:
+		return true
+	}
+	return false
+}
+
+// write outputs the data using the app's output format.  The table function is called to write the table formatted
+// output, with each row written as a tab-separated line.
+func (a *app) write(data interface{}, table func(w io.Writer)) error {
+	switch a.format {
+	case formatJson:
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	case formatYaml:
+		b, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = a.out.Write(b)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+func row(w io.Writer, cols ...interface{}) {
+	for i, c := range cols {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, c)
+	}
+	fmt.Fprintln(w)
+}
@@ -1,33 +1,42 @@
 package config
 
-import (
-	"strconv"
-)
+import "sync"
 
+// awsConfigResolver settings are protected by the mutex, so the resolver is safe for concurrent use as long as the
+// AwsConfigProvider is (the ini and memory providers in this package are)
 type awsConfigResolver struct {
 	lookupDefaultProfile bool
 	lookupSourceProfile  bool
 	configProvider       AwsConfigProvider
+	policy               *ProfilePolicy
+	log                  Logger
+	mu                   sync.RWMutex
 }
 
 // NewAwsConfigResolver creates a default AWS config resolver which will lookup information in the INI config source for
 // the default profile, and source_profile (if configured), and merge with the data in the provided profile
-func NewAwsConfigResolver(source interface{}) (*awsConfigResolver, error) {
-	cp, err := NewIniConfigProvider(source)
+func NewAwsConfigResolver(source interface{}, opts ...Option) (*awsConfigResolver, error) {
+	cp, err := NewIniConfigProvider(source, opts...)
 	if err != nil {
 		return nil, err
 	}
 
+	o := newOptions(opts)
 	return &awsConfigResolver{
 		lookupDefaultProfile: true,
 		lookupSourceProfile:  true,
 		configProvider:       cp,
+		policy:               o.policy(),
+		log:                  o.log,
 	}, nil
 }
 
 // LookupDefaultProfile is a fluent method for enabling (or disabling) the inclusion of default profile
 // data in the resolved configuration
 func (r *awsConfigResolver) WithLookupDefaultProfile(b bool) *awsConfigResolver {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.lookupDefaultProfile = b
 	return r
 }
@@ -35,6 +44,9 @@ func (r *awsConfigResolver) WithLookupDefaultProfile(b bool) *awsConfigResolver
 // LookupSourceProfile is a fluent method for enabling (or disabling) the inclusion of source_profile
 // data in the resolved configuration, if the source_profile attribute is found in the target profile
 func (r *awsConfigResolver) WithLookupSourceProfile(b bool) *awsConfigResolver {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.lookupSourceProfile = b
 	return r
 }
@@ -42,21 +54,36 @@ func (r *awsConfigResolver) WithLookupSourceProfile(b bool) *awsConfigResolver {
 // WithConfigProvider is a fluent method for setting the AwsConfigProvider type of the resolver.
 // This will be used to do the work in the Resolve() method
 func (r *awsConfigResolver) WithConfigProvider(p AwsConfigProvider) *awsConfigResolver {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.configProvider = p
 	return r
 }
 
+// WithProfilePolicy is a fluent method for setting the ProfilePolicy used to select the profile when a profile
+// name is not provided to Resolve(), and the name of the default profile
+func (r *awsConfigResolver) WithProfilePolicy(p *ProfilePolicy) *awsConfigResolver {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.policy = p
+	return r
+}
+
 // Merge will combine the attributes of the provided AwsConfig types and return it as a single AwsConfig.
 // Objects later in the input list will overwrite values in earlier objects if the value for the attribute is
-// not empty, or the explicit string "0"
+// not empty, or the explicit string "0".  The profile each attribute was merged from is available via Source()
 func (r *awsConfigResolver) Merge(config ...*AwsConfig) (*AwsConfig, error) {
 	c := new(AwsConfig)
 	c.rawAttributes = make(map[string]string)
+	c.sources = make(map[string]string)
 
 	for _, x := range config {
 		for k, v := range x.rawAttributes {
 			if len(v) > 0 && v != "0" {
 				c.rawAttributes[k] = v
+				c.sources[k] = x.Source(k)
 			}
 		}
 
@@ -66,20 +93,7 @@ func (r *awsConfigResolver) Merge(config ...*AwsConfig) (*AwsConfig, error) {
 	}
 
 	if len(c.rawAttributes) > 0 {
-		durSec, err := strconv.ParseInt(c.Get("duration_seconds"), 0, 0)
-		if err != nil {
-			durSec = 0
-		}
-
-		c.CaBundle = c.Get("ca_bundle")
-		c.CredentialSource = c.Get("credential_source")
-		c.DurationSeconds = int(durSec)
-		c.ExternalId = c.Get("external_id")
-		c.MfaSerial = c.Get("mfa_serial")
-		c.Region = c.Get("region")
-		c.RoleArn = c.Get("role_arn")
-		c.RoleSessionName = c.Get("role_session_name")
-		c.SourceProfile = c.Get("source_profile")
+		c.setFields()
 	}
 
 	return c, nil
@@ -87,39 +101,113 @@ func (r *awsConfigResolver) Merge(config ...*AwsConfig) (*AwsConfig, error) {
 
 // Resolve gathers the configuration attributes for the given profile.  If the resolver is set to lookup default or
 // source_profile configuration, that data is also merged in to the returned configuration object.  The resolution order
-// is: default, source_profile, profile
+// is: default, source_profile, profile.  If the profile is not provided, it is selected using the resolver's ProfilePolicy.
+// Each of these profiles includes the attributes inherited using the x_inherit attribute (see InheritAttribute), and
+// ${name} variable references in the merged attribute values are replaced with the value of the named attribute.
 func (r *awsConfigResolver) Resolve(profile ...string) (*AwsConfig, error) {
-	if profile == nil || len(profile) < 1 {
-		// quick path ... return default profile data
-		return r.configProvider.Config()
-	}
+	// take a copy of the settings, so the lock isn't held while calling the provider
+	r.mu.RLock()
+	cp, lookupDefault, lookupSource := r.configProvider, r.lookupDefaultProfile, r.lookupSourceProfile
+	policy := policyOrDefault(r.policy)
+	log := loggerOrNop(r.log)
+	r.mu.RUnlock()
 
-	c := make([]*AwsConfig, 0)
+	name := policy.Resolve(firstOrEmpty(profile))
+	log.Debug("resolving profile", "profile", name)
 
-	p, err := r.configProvider.Config(profile...)
+	p, err := r.inherited(cp, name, log)
 	if err != nil {
 		return nil, err
 	}
 
-	if r.lookupDefaultProfile {
-		d, err := r.configProvider.Config()
+	if len(firstOrEmpty(profile)) < 1 && name == policy.DefaultName() {
+		// quick path ... return default profile data
+		log.Debug("using default profile only", "profile", name)
+		return interpolate(p), nil
+	}
+
+	c := make([]*AwsConfig, 0)
+
+	if lookupDefault {
+		log.Debug("merging default profile", "profile", name, "default", policy.DefaultName())
+		d, err := r.inherited(cp, policy.DefaultName(), log)
 		if err != nil {
 			return nil, err
 		}
 		c = append(c, d)
 	}
 
-	if r.lookupSourceProfile && len(p.SourceProfile) > 0 {
-		s, err := r.configProvider.Config(p.SourceProfile)
+	if lookupSource && len(p.SourceProfile) > 0 {
+		err = checkSourceProfiles(name, p.SourceProfile, func(n string) (string, bool) {
+			s, err := r.inherited(cp, n, log)
+			if err != nil {
+				return "", false
+			}
+			return s.SourceProfile, true
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		log.Debug("merging source_profile", "profile", name, "source_profile", p.SourceProfile)
+		s, err := r.inherited(cp, p.SourceProfile, log)
 		if err != nil {
 			return nil, err
 		}
 		c = append(c, s)
 	}
 
-	return r.Merge(append(c, p)...)
+	m, err := r.Merge(append(c, p)...)
+	if err != nil {
+		return nil, err
+	}
+	return interpolate(m), nil
+}
+
+// inherited returns the configuration of the profile, merged with the attributes of the profiles it inherits from
+func (r *awsConfigResolver) inherited(cp AwsConfigProvider, name string, log Logger) (*AwsConfig, error) {
+	chain, err := inheritChain(cp, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chain) < 2 {
+		return chain[0], nil
+	}
+
+	log.Debug("merging inherited profiles", "profile", name, InheritAttribute, chain[len(chain)-2].Profile)
+	return mergeInherited(chain), nil
+}
+
+// profileConfig returns the configuration of the profile, including the attributes it inherits, without merging the
+// default profile or source_profile
+func (r *awsConfigResolver) profileConfig(profile ...string) (*AwsConfig, error) {
+	r.mu.RLock()
+	cp, policy, log := r.configProvider, policyOrDefault(r.policy), loggerOrNop(r.log)
+	r.mu.RUnlock()
+
+	c, err := r.inherited(cp, policy.Resolve(firstOrEmpty(profile)), log)
+	if err != nil {
+		return nil, err
+	}
+	return interpolate(c), nil
 }
 
 func (r *awsConfigResolver) ListProfiles(roles bool) []string {
-	return r.configProvider.ListProfiles(roles)
+	return r.provider().ListProfiles(roles)
+}
+
+// QueryProfiles will return the profile metadata matching the query from the resolver's AwsConfigProvider.
+// If the provider does not support queries, an empty array is returned.
+func (r *awsConfigResolver) QueryProfiles(q *ProfileQuery) []ProfileEntry {
+	if qp, ok := r.provider().(ProfileQuerier); ok {
+		return qp.QueryProfiles(q)
+	}
+	return []ProfileEntry{}
+}
+
+func (r *awsConfigResolver) provider() AwsConfigProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.configProvider
 }
@@ -81,6 +81,10 @@ func TestAwsConfigResolver_Resolve(t *testing.T) {
 			c.ExternalId != "qq" || len(c.MfaSerial) < 1 || len(c.RoleArn) < 1 || len(c.rawAttributes) < 5 {
 			t.Error("data mismatch")
 		}
+
+		if c.Source("region") != "mfa" || c.Source("mfa_serial") != DefaultProfileName || len(c.Source("not-an-attr")) > 0 {
+			t.Error("source mismatch")
+		}
 	})
 
 	t.Run("bad profile", func(t *testing.T) {
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"io/ioutil"
+	"sync"
+	"testing"
+)
+
+// these tests are most useful when run with the race detector (go test -race)
+
+const workers = 8
+
+func runConcurrent(t *testing.T, fns ...func(i int) error) {
+	errs := make(chan error, workers*len(fns))
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		for _, f := range fns {
+			wg.Add(1)
+			go func(i int, f func(int) error) {
+				defer wg.Done()
+				if err := f(i); err != nil {
+					errs <- err
+				}
+			}(i, f)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestIniCredentialProvider_Concurrent(t *testing.T) {
+	p, err := NewIniCredentialProvider(credFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer p.Close()
+
+	runConcurrent(t,
+		func(i int) error {
+			c, err := p.Credentials("other")
+			if err != nil {
+				return err
+			}
+
+			if !c.HasKeys() {
+				return fmt.Errorf("incomplete credentials")
+			}
+			return nil
+		},
+		func(i int) error {
+			v := credentials.Value{AccessKeyID: fmt.Sprintf("AKIA%d", i), SecretAccessKey: fmt.Sprintf("secret%d", i)}
+			return p.UpdateCredentials("other", v)
+		},
+		func(i int) error {
+			_, err := p.ExpiresAt("other")
+			return err
+		},
+		func(i int) error {
+			_, err := p.WriteTo(ioutil.Discard)
+			return err
+		},
+	)
+}
+
+func TestIniConfigProvider_Concurrent(t *testing.T) {
+	p, err := NewIniConfigProvider(ConfFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer p.Close()
+
+	runConcurrent(t,
+		func(i int) error {
+			_, err := p.Config("other")
+			return err
+		},
+		func(i int) error {
+			return p.SetAttribute(fmt.Sprintf("new%d", i%2), "region", fmt.Sprintf("us-east-%d", i%2+1))
+		},
+		func(i int) error {
+			return p.UnsetAttribute("uncommon", "custom_attribute")
+		},
+		func(i int) error {
+			p.QueryProfiles(nil)
+			p.Lint()
+			return nil
+		},
+	)
+}
+
+func TestAwsConfigResolver_Concurrent(t *testing.T) {
+	r, err := NewAwsConfigResolver(ConfFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	cp := r.provider().(*IniConfigProvider)
+	defer cp.Close()
+
+	runConcurrent(t,
+		func(i int) error {
+			c, err := r.Resolve("mfa")
+			if err != nil {
+				return err
+			}
+
+			if c.Profile != "mfa" {
+				return fmt.Errorf("profile mismatch")
+			}
+			return nil
+		},
+		func(i int) error {
+			r.WithLookupDefaultProfile(i%2 == 0).WithLookupSourceProfile(i%2 == 1)
+			return nil
+		},
+		func(i int) error {
+			return cp.SetAttribute("mfa", "region", fmt.Sprintf("eu-west-%d", i%3+1))
+		},
+		func(i int) error {
+			r.ListProfiles(true)
+			return nil
+		},
+	)
+}
+
+func TestMemoryProviders_Concurrent(t *testing.T) {
+	b := NewProfileBuilder().Region("us-east-1").Credentials("AKIAMOCK", "secret")
+	cp, cr := b.ConfigProvider(), b.CredentialProvider()
+
+	runConcurrent(t,
+		func(i int) error {
+			cp.WithProfile(fmt.Sprintf("p%d", i), map[string]string{"region": "us-west-2"})
+			_, err := cp.Config()
+			return err
+		},
+		func(i int) error {
+			cp.QueryProfiles(nil)
+			return nil
+		},
+		func(i int) error {
+			return cr.UpdateCredentials(fmt.Sprintf("p%d", i), credentials.Value{AccessKeyID: "a", SecretAccessKey: "s"})
+		},
+		func(i int) error {
+			cr.ListProfiles()
+			_, err := cr.Credentials()
+			return err
+		},
+		func(i int) error {
+			// the builder isn't safe for concurrent use, but the providers are while it adds profiles
+			if i == 0 {
+				for j := 0; j < workers; j++ {
+					b.Profile(fmt.Sprintf("b%d", j)).Region("us-east-2").Credentials("AKIAMOCK", "secret").SessionToken("token")
+				}
+			}
+			return nil
+		},
+	)
+}
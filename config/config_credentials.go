@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/go-ini/ini"
+	"strings"
+)
+
+// credentialAttributes are the attributes holding credentials, which the AWS CLI will use if found in the config file,
+// but belong in the credentials file
+var credentialAttributes = []string{"aws_access_key_id", "aws_secret_access_key", "aws_session_token"}
+
+// CredentialProfiles returns the names of the profiles in the config file which contain credential attributes
+// (aws_access_key_id, aws_secret_access_key, or aws_session_token), sorted by profile name
+func (p *IniConfigProvider) CredentialProfiles() []string {
+	entries := make([]ProfileEntry, 0)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, s := range p.Sections() {
+		if p.isProfileSection(s.Name()) && hasCredentialAttributes(s) {
+			entries = append(entries, ProfileEntry{Name: strings.TrimPrefix(s.Name(), "profile "), Section: s.Name()})
+		}
+	}
+	sortProfileEntries(entries)
+
+	profiles := make([]string, len(entries))
+	for i, e := range entries {
+		profiles[i] = e.Name
+	}
+	return profiles
+}
+
+// Credentials will return the credentials found in the config file for the provided profile, so the IniConfigProvider
+// can be used as an AwsCredentialProvider to read credentials the same way the AWS CLI does.  If the profile is nil or
+// empty, the profile is selected using the provider's ProfilePolicy.  Profiles without both an access key and secret
+// key return an IncompleteCredentialsError.
+func (p *IniConfigProvider) Credentials(profile ...string) (credentials.Value, error) {
+	v := credentials.Value{}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	name := policyOrDefault(p.policy).Resolve(firstOrEmpty(profile))
+
+	s, err := p.configProfile(name)
+	if err != nil {
+		return v, err
+	}
+
+	c := new(awsCredentials)
+	if err := s.MapTo(c); err != nil {
+		return v, err
+	}
+
+	v.AccessKeyID = c.AccessKey
+	v.SecretAccessKey = c.SecretKey
+	v.SessionToken = c.SessionToken
+	if !v.HasKeys() {
+		return v, &IncompleteCredentialsError{Profile: name}
+	}
+
+	return v, nil
+}
+
+// MigrateCredentials moves the credential attributes of the given profiles from the config file to the credentials
+// file, creating the credentials file section if necessary.  If no profiles are provided, all profiles returned by
+// CredentialProfiles() are migrated.  It is an error if the credentials file already has different credentials for
+// a profile, and no changes are made to either file for that profile.  A session token in the credentials file is
+// removed if the migrated credentials don't include one.  The names of the migrated profiles are returned.
+// Updates are only made to the in-memory representation of the data, it is the caller's responsibility to persist
+// both the config and credentials files.
+func (p *IniConfigProvider) MigrateCredentials(creds *IniCredentialProvider, profile ...string) ([]string, error) {
+	if len(profile) < 1 {
+		profile = p.CredentialProfiles()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	creds.mu.Lock()
+	defer creds.mu.Unlock()
+
+	migrated := make([]string, 0)
+	for _, name := range profile {
+		s, err := p.configProfile(name)
+		if err != nil {
+			return migrated, err
+		}
+
+		if !hasCredentialAttributes(s) {
+			continue
+		}
+
+		if err := migrateSection(s, creds, name); err != nil {
+			return migrated, err
+		}
+		migrated = append(migrated, name)
+	}
+
+	return migrated, nil
+}
+
+// migrateSection copies the credential attributes of the config file section to the credentials file section for the
+// profile, and removes them from the config file section
+func migrateSection(s *ini.Section, creds *IniCredentialProvider, name string) error {
+	cs, err := creds.profile(name, nil)
+	if err != nil {
+		if cs, err = creds.NewSection(name); err != nil {
+			return err
+		}
+	}
+
+	src, dst := s.KeysHash(), cs.KeysHash()
+	for _, k := range credentialAttributes {
+		if v := dst[k]; len(v) > 0 && len(src[k]) > 0 && v != src[k] {
+			return fmt.Errorf("profile '%s' has different credentials in the credentials file", name)
+		}
+	}
+
+	for _, k := range credentialAttributes {
+		if v, ok := src[k]; ok {
+			if _, err := cs.NewKey(k, v); err != nil {
+				return err
+			}
+			s.DeleteKey(k)
+		}
+	}
+
+	// a session token left in the credentials file would be used with the migrated keys
+	if len(src["aws_session_token"]) < 1 {
+		cs.DeleteKey("aws_session_token")
+	}
+
+	return nil
+}
+
+func hasCredentialAttributes(s *ini.Section) bool {
+	for _, k := range credentialAttributes {
+		if s.HasKey(k) {
+			return true
+		}
+	}
+	return false
+}
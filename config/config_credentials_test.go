@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var credentialsConfig = []byte(`[default]
+region = us-east-1
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = DefaultSecret
+
+[profile partial]
+aws_access_key_id = AKIAPARTIAL
+
+[profile clean]
+region = us-west-2
+
+[profile conflict]
+aws_access_key_id = AKIACONFLICT
+aws_secret_access_key = ConflictSecret
+`)
+
+func TestIniConfigProvider_CredentialProfiles(t *testing.T) {
+	p, err := NewIniConfigProvider(credentialsConfig)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if c := p.CredentialProfiles(); strings.Join(c, ",") != "conflict,default,partial" {
+		t.Errorf("unexpected profiles: %v", c)
+	}
+}
+
+func TestIniConfigProvider_Credentials(t *testing.T) {
+	p, err := NewIniConfigProvider(credentialsConfig, WithEnvironment(MapEnvironment{}))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("default", func(t *testing.T) {
+		v, err := p.Credentials()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if v.AccessKeyID != "AKIADEFAULT" || v.SecretAccessKey != "DefaultSecret" {
+			t.Error("unexpected credentials")
+		}
+	})
+
+	t.Run("incomplete", func(t *testing.T) {
+		if _, err := p.Credentials("partial"); !errors.Is(err, ErrIncompleteCredentials) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("bad profile", func(t *testing.T) {
+		if _, err := p.Credentials("nope"); !errors.Is(err, ErrProfileNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestIniConfigProvider_MigrateCredentials(t *testing.T) {
+	p, err := NewIniConfigProvider(credentialsConfig)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	c, err := NewIniCredentialProvider([]byte("[partial]\naws_secret_access_key = PartialSecret\n\n[conflict]\naws_access_key_id = AKIAOTHER\n"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("conflict", func(t *testing.T) {
+		m, err := p.MigrateCredentials(c, "conflict")
+		if err == nil || len(m) > 0 {
+			t.Error("did not receive expected error")
+			return
+		}
+
+		if x, _ := p.Credentials("conflict"); x.AccessKeyID != "AKIACONFLICT" {
+			t.Error("config file modified")
+		}
+	})
+
+	t.Run("good", func(t *testing.T) {
+		m, err := p.MigrateCredentials(c, "default", "partial", "clean")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if strings.Join(m, ",") != "default,partial" {
+			t.Errorf("unexpected migrated profiles: %v", m)
+		}
+
+		if x := p.CredentialProfiles(); len(x) != 1 || x[0] != "conflict" {
+			t.Errorf("unexpected profiles: %v", x)
+		}
+
+		for _, n := range m {
+			if _, err := c.Credentials(n); err != nil {
+				t.Error(err)
+			}
+		}
+
+		if cfg, _ := p.Config("default"); cfg.Region != "us-east-1" {
+			t.Error("non-credential attributes were migrated")
+		}
+
+		b := new(bytes.Buffer)
+		if _, err := p.WriteTo(b); err != nil || strings.Contains(b.String(), "DefaultSecret") {
+			t.Errorf("secrets remain in config file: %s", b.String())
+		}
+	})
+
+	t.Run("stale session token", func(t *testing.T) {
+		p, err := NewIniConfigProvider([]byte("[profile keys]\naws_access_key_id = AKIAKEYS\naws_secret_access_key = KeysSecret\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := NewIniCredentialProvider([]byte("[keys]\naws_session_token = StaleToken\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := p.MigrateCredentials(c, "keys"); err != nil {
+			t.Fatal(err)
+		}
+
+		if x, err := c.Credentials("keys"); err != nil || x.AccessKeyID != "AKIAKEYS" || len(x.SessionToken) > 0 {
+			t.Errorf("unexpected credentials: %v %v", RedactedValue(x), err)
+		}
+	})
+
+	t.Run("bad profile", func(t *testing.T) {
+		if _, err := p.MigrateCredentials(c, "nope"); !errors.Is(err, ErrProfileNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+// Package configtest provides helpers for testing code which uses the config package, by temporarily setting the
+// environment variables and files the config package reads.  The helpers modify process-wide state, so tests using
+// them should not run in parallel.
+package configtest
+
+import (
+	"github.com/mmmorris1975/aws-config/config"
+	"io/ioutil"
+	"os"
+)
+
+// Setenv sets the environment variables to the values in the map, and returns a function which restores the previous
+// values (unsetting variables which were not previously set).  It's expected that the returned function is deferred.
+func Setenv(vars map[string]string) func() {
+	prev := make(map[string]*string, len(vars))
+
+	for k, v := range vars {
+		if o, ok := os.LookupEnv(k); ok {
+			prev[k] = &o
+		} else {
+			prev[k] = nil
+		}
+		os.Setenv(k, v)
+	}
+
+	return func() {
+		for k, v := range prev {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	}
+}
+
+// WithProfile sets the AWS_PROFILE environment variable, and returns a function which restores the previous value
+func WithProfile(name string) func() {
+	return Setenv(map[string]string{config.ProfileEnvVar: name})
+}
+
+// WithConfigFile sets the AWS_CONFIG_FILE environment variable, and returns a function which restores the previous value
+func WithConfigFile(path string) func() {
+	return Setenv(map[string]string{config.ConfigFileEnvVar: path})
+}
+
+// WithCredentialsFile sets the AWS_SHARED_CREDENTIALS_FILE environment variable, and returns a function which restores
+// the previous value
+func WithCredentialsFile(path string) func() {
+	return Setenv(map[string]string{config.CredentialsFileEnvVar: path})
+}
+
+// WithConfigData writes the data to a temporary file and sets AWS_CONFIG_FILE to the file path.  The returned function
+// restores the previous value of AWS_CONFIG_FILE and removes the file.
+func WithConfigData(data string) (func(), error) {
+	return withTempFile(data, WithConfigFile)
+}
+
+// WithCredentialsData writes the data to a temporary file and sets AWS_SHARED_CREDENTIALS_FILE to the file path.  The
+// returned function restores the previous value of AWS_SHARED_CREDENTIALS_FILE and removes the file.
+func WithCredentialsData(data string) (func(), error) {
+	return withTempFile(data, WithCredentialsFile)
+}
+
+func withTempFile(data string, set func(string) func()) (func(), error) {
+	f, err := ioutil.TempFile("", "configtest-")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(data); err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+
+	restore := set(f.Name())
+	return func() {
+		restore()
+		os.Remove(f.Name())
+	}, nil
+}
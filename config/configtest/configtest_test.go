@@ -0,0 +1,85 @@
+package configtest
+
+import (
+	"github.com/mmmorris1975/aws-config/config"
+	"os"
+	"testing"
+)
+
+func TestSetenv(t *testing.T) {
+	os.Setenv("CONFIGTEST_A", "a")
+	defer os.Unsetenv("CONFIGTEST_A")
+	os.Unsetenv("CONFIGTEST_B")
+
+	restore := Setenv(map[string]string{"CONFIGTEST_A": "x", "CONFIGTEST_B": "y"})
+	if os.Getenv("CONFIGTEST_A") != "x" || os.Getenv("CONFIGTEST_B") != "y" {
+		t.Error("variables not set")
+	}
+	restore()
+
+	if os.Getenv("CONFIGTEST_A") != "a" {
+		t.Error("variable not restored")
+	}
+
+	if _, ok := os.LookupEnv("CONFIGTEST_B"); ok {
+		t.Error("variable not unset")
+	}
+}
+
+func TestWithProfile(t *testing.T) {
+	defer WithProfile("other")()
+
+	if config.ResolveProfile(nil) != "other" {
+		t.Error("profile not set")
+	}
+}
+
+func TestWithConfigData(t *testing.T) {
+	restore, err := WithConfigData("[default]\nregion = us-east-2\n")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	f := os.Getenv(config.ConfigFileEnvVar)
+
+	p, err := config.NewIniConfigProvider(nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	c, err := p.Config()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if c.Region != "us-east-2" {
+		t.Error("data mismatch")
+	}
+
+	restore()
+	if _, err := os.Stat(f); err == nil {
+		t.Error("file not removed")
+	}
+}
+
+func TestWithCredentialsData(t *testing.T) {
+	restore, err := WithCredentialsData("[default]\naws_access_key_id = ak\naws_secret_access_key = sk\n")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer restore()
+
+	p, err := config.NewIniCredentialProvider(nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if v, err := p.Credentials(); err != nil || v.AccessKeyID != "ak" {
+		t.Error("credential mismatch")
+	}
+}
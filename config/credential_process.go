@@ -0,0 +1,127 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"io"
+	"time"
+)
+
+// CredentialProcessVersion is the version of the credential_process output schema
+const CredentialProcessVersion = 1
+
+// ExpirationAttribute is the credentials file attribute holding the expiration time of session credentials,
+// in RFC3339 format.  This is the same attribute used by tools like saml2aws and gimme-aws-creds
+const ExpirationAttribute = "x_security_token_expires"
+
+// ExpiringCredentialProvider is an interface defining the contract for credential providers which are able to report
+// the expiration time of the credentials for a profile.  A zero time means the credentials do not expire.
+type ExpiringCredentialProvider interface {
+	AwsCredentialProvider
+	ExpiresAt(profile ...string) (time.Time, error)
+}
+
+// CredentialProcessOutput is the JSON document written by an external credential_process, as defined in the
+// AWS CLI documentation
+type CredentialProcessOutput struct {
+	Version         int
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string `json:",omitempty"`
+	Expiration      string `json:",omitempty"`
+}
+
+// WriteCredentialProcess writes the credentials to w using the credential_process JSON schema.  If expires is the
+// zero time, the Expiration attribute is not included in the output.
+func WriteCredentialProcess(w io.Writer, creds credentials.Value, expires time.Time) error {
+	if !creds.HasKeys() {
+		return &IncompleteCredentialsError{}
+	}
+
+	o := CredentialProcessOutput{
+		Version:         CredentialProcessVersion,
+		AccessKeyId:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+	}
+
+	if !expires.IsZero() {
+		o.Expiration = expires.UTC().Format(time.RFC3339)
+	}
+
+	b, err := json.Marshal(&o)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// EmitCredentialProcess resolves the profile configuration, looks up the credentials for the profile (see
+// ResolveCredentials), and writes them to w using the credential_process JSON schema.  If the credential provider
+// is an ExpiringCredentialProvider, the expiration time of the credentials is included in the output.  Nothing is
+// written to w if an error is returned.
+func EmitCredentialProcess(w io.Writer, r AwsConfigResolver, p AwsCredentialProvider, profile ...string) error {
+	v, n, err := ResolveCredentials(r, p, profile...)
+	if err != nil {
+		return err
+	}
+
+	var exp time.Time
+	if ep, ok := p.(ExpiringCredentialProvider); ok {
+		if exp, err = ep.ExpiresAt(n); err != nil {
+			return err
+		}
+	}
+
+	return WriteCredentialProcess(w, v, exp)
+}
+
+// ResolveCredentials resolves the configuration for the profile, then looks up credentials for the profile using the
+// credential provider.  Role profiles (with a role_arn attribute) return a RoleProfileError, since the stored
+// credentials of the profile or its source_profile are not the credentials of the role.  For the resolvers in this
+// package, only the attributes of the profile and the profiles it inherits are checked for a role_arn, not those merged
+// from the default profile or source_profile.  Profiles only found in the credentials file have no configuration to
+// check.  The credentials and the name of the profile they were found in are returned.
+func ResolveCredentials(r AwsConfigResolver, p AwsCredentialProvider, profile ...string) (credentials.Value, string, error) {
+	name := firstOrEmpty(profile)
+
+	c, err := profileConfig(r, profile...)
+
+	// the profile may only be in the credentials file, but a missing profile it inherits from is an error
+	var nf *ProfileNotFoundError
+	if errors.As(err, &nf) && (len(name) < 1 || nf.Profile == name) {
+		c, err = &AwsConfig{Profile: nf.Profile}, nil
+	}
+
+	if err != nil {
+		return credentials.Value{}, "", err
+	}
+
+	if len(c.RoleArn) > 0 {
+		return credentials.Value{}, "", &RoleProfileError{Profile: c.Profile, RoleArn: c.RoleArn}
+	}
+
+	v, err := p.Credentials(c.Profile)
+	if err != nil {
+		return v, "", err
+	}
+	return v, c.Profile, nil
+}
+
+// profileResolver is implemented by resolvers able to return the configuration of a profile without the attributes of
+// the default profile or source_profile merged in
+type profileResolver interface {
+	profileConfig(profile ...string) (*AwsConfig, error)
+}
+
+// profileConfig returns the configuration of the profile, without the merged attributes if the resolver supports it
+func profileConfig(r AwsConfigResolver, profile ...string) (*AwsConfig, error) {
+	if pr, ok := r.(profileResolver); ok {
+		return pr.profileConfig(profile...)
+	}
+	return r.Resolve(profile...)
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"testing"
+	"time"
+)
+
+func TestWriteCredentialProcess(t *testing.T) {
+	t.Run("static", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		if err := WriteCredentialProcess(b, credentials.Value{AccessKeyID: "ak", SecretAccessKey: "sk"}, time.Time{}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if b.String() != `{"Version":1,"AccessKeyId":"ak","SecretAccessKey":"sk"}`+"\n" {
+			t.Errorf("unexpected output: %s", b.String())
+		}
+	})
+
+	t.Run("session", func(t *testing.T) {
+		exp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("x", 3600))
+		v := credentials.Value{AccessKeyID: "ak", SecretAccessKey: "sk", SessionToken: "tok"}
+
+		b := new(bytes.Buffer)
+		if err := WriteCredentialProcess(b, v, exp); err != nil {
+			t.Error(err)
+			return
+		}
+
+		o := new(CredentialProcessOutput)
+		if err := json.Unmarshal(b.Bytes(), o); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if o.Version != 1 || o.SessionToken != "tok" || o.Expiration != "2020-01-02T02:04:05Z" {
+			t.Errorf("unexpected output: %s", b.String())
+		}
+	})
+
+	t.Run("incomplete", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		if err := WriteCredentialProcess(b, credentials.Value{AccessKeyID: "ak"}, time.Time{}); err == nil {
+			t.Error("did not receive expected error")
+		}
+
+		if b.Len() > 0 {
+			t.Error("unexpected output")
+		}
+	})
+}
+
+func TestEmitCredentialProcess(t *testing.T) {
+	r, err := NewAwsConfigResolver([]byte("[default]\n[profile role]\nrole_arn = arn:aws:iam::123456789012:role/r\nsource_profile = src\n[profile src]\n[profile none]\n"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	p, err := NewIniCredentialProvider([]byte("[src]\naws_access_key_id = ak\naws_secret_access_key = sk\naws_session_token = tok\nx_security_token_expires = 2020-01-02T03:04:05Z\n"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("good", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		if err := EmitCredentialProcess(b, r, p, "src"); err != nil {
+			t.Error(err)
+			return
+		}
+
+		o := new(CredentialProcessOutput)
+		if err := json.Unmarshal(b.Bytes(), o); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if o.AccessKeyId != "ak" || o.Expiration != "2020-01-02T03:04:05Z" {
+			t.Errorf("unexpected output: %s", b.String())
+		}
+	})
+
+	t.Run("role profile", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		if err := EmitCredentialProcess(b, r, p, "role"); !errors.Is(err, ErrRoleProfile) {
+			t.Errorf("did not receive expected error: %v", err)
+		}
+
+		if b.Len() > 0 {
+			t.Errorf("unexpected output: %s", b.String())
+		}
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		if err := EmitCredentialProcess(b, r, p, "none"); err == nil {
+			t.Error("did not receive expected error")
+		}
+
+		if b.Len() > 0 {
+			t.Error("unexpected output")
+		}
+	})
+
+	t.Run("bad profile", func(t *testing.T) {
+		if err := EmitCredentialProcess(new(bytes.Buffer), r, p, "not-a-profile"); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestResolveCredentials(t *testing.T) {
+	r, err := NewAwsConfigResolver([]byte(`
+[default]
+role_arn = arn:aws:iam::123456789012:role/default
+
+[profile plain]
+region = us-east-2
+
+[profile role]
+role_arn = arn:aws:iam::123456789012:role/r
+
+[profile child]
+x_inherit = role
+
+[profile orphan]
+x_inherit = missing
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewIniCredentialProvider([]byte("[plain]\naws_access_key_id = ak\naws_secret_access_key = sk\n[creds-only]\naws_access_key_id = ak2\naws_secret_access_key = sk2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("default role_arn", func(t *testing.T) {
+		v, n, err := ResolveCredentials(r, p, "plain")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n != "plain" || v.AccessKeyID != "ak" {
+			t.Errorf("unexpected credentials for %s: %s", n, v.AccessKeyID)
+		}
+	})
+
+	t.Run("credentials only", func(t *testing.T) {
+		v, n, err := ResolveCredentials(r, p, "creds-only")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n != "creds-only" || v.AccessKeyID != "ak2" {
+			t.Errorf("unexpected credentials for %s: %s", n, v.AccessKeyID)
+		}
+	})
+
+	t.Run("inherited role_arn", func(t *testing.T) {
+		if _, _, err := ResolveCredentials(r, p, "child"); !errors.Is(err, ErrRoleProfile) {
+			t.Errorf("did not receive expected error: %v", err)
+		}
+	})
+
+	t.Run("missing inherited profile", func(t *testing.T) {
+		var e *ProfileNotFoundError
+		if _, _, err := ResolveCredentials(r, p, "orphan"); !errors.As(err, &e) || e.Profile != "missing" {
+			t.Errorf("did not receive expected error: %v", err)
+		}
+	})
+
+	t.Run("missing profile", func(t *testing.T) {
+		if _, _, err := ResolveCredentials(r, p, "not-a-profile"); !errors.Is(err, ErrProfileNotFound) {
+			t.Errorf("did not receive expected error: %v", err)
+		}
+	})
+}
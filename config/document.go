@@ -0,0 +1,399 @@
+package config
+
+import (
+	"github.com/go-ini/ini"
+	"strings"
+	"unicode"
+)
+
+// document is the line layout of the INI data a file was loaded from.  Changes made through the ini.File API are
+// written back using the document, so the output differs from the source only where the data changed: untouched lines
+// are written exactly as read (keeping comments, blank lines, key order and quoting), changed values are replaced in
+// place, deleted keys and sections are removed, and new keys are added using the formatting of the existing section.
+type document struct {
+	sections []*docSection
+	// values are the key values as originally parsed, by section name, used to find changed keys.  When the data is
+	// loaded from multiple sources, these are the merged values, so only changes are written to the document's source.
+	values map[string]map[string]string
+	eol    string
+	final  bool
+}
+
+// docSection is a section of the document.  The DEFAULT section (the keys before the first section header) has no header.
+type docSection struct {
+	name    string
+	leading []string
+	header  string
+	entries []*docEntry
+}
+
+// docEntry is a single blank, comment, or key line.  Key entries may span several lines, for multi-line values.
+type docEntry struct {
+	text   string
+	key    string
+	name   string
+	indent string
+	sep    string
+	value  string
+	suffix string
+	quote  byte
+	col    int
+}
+
+// newDocument builds the document from the raw data, and the ini.File parsed from that data (and any other sources)
+func newDocument(raw []byte, f *ini.File) *document {
+	d := &document{eol: "\n", values: make(map[string]map[string]string)}
+	for _, s := range f.Sections() {
+		d.values[s.Name()] = s.KeysHash()
+	}
+
+	text := string(raw)
+	if strings.Contains(text, "\r\n") {
+		d.eol = "\r\n"
+	}
+
+	// data without any line ending (including the empty default profile) is treated as a complete line
+	d.final = strings.HasSuffix(text, "\n") || !strings.Contains(text, "\n")
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "\n"), "\r")
+
+	lines := make([]string, 0)
+	if len(text) > 0 {
+		lines = strings.Split(text, "\n")
+	}
+
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+
+	cur := &docSection{name: ini.DefaultSection}
+	d.sections = append(d.sections, cur)
+
+	for i := 0; i < len(lines); i++ {
+		t := strings.TrimSpace(strings.TrimPrefix(lines[i], "\ufeff"))
+
+		switch {
+		case len(t) < 1 || t[0] == '#' || t[0] == ';':
+			cur.entries = append(cur.entries, &docEntry{text: lines[i]})
+		case t[0] == '[':
+			name := t[1:]
+			if j := strings.LastIndex(t, "]"); j > 0 {
+				name = t[1:j]
+			}
+
+			cur = &docSection{name: strings.TrimSpace(name), header: lines[i], leading: cur.trailingComments()}
+			d.sections = append(d.sections, cur)
+		default:
+			e, n := d.parseEntry(lines[i:])
+			cur.entries = append(cur.entries, e)
+			i += n - 1
+		}
+	}
+
+	return d
+}
+
+// parseEntry parses the key entry at the start of the lines, returning the entry and the number of lines it spans
+func (d *document) parseEntry(lines []string) (*docEntry, int) {
+	l := lines[0]
+	t := strings.TrimLeftFunc(strings.TrimPrefix(l, "\ufeff"), unicode.IsSpace)
+	e := &docEntry{text: l, indent: l[:len(l)-len(t)]}
+
+	name, rest := splitKeyName(t)
+	e.key, e.name = name, t[:len(t)-len(rest)]
+
+	i := strings.IndexAny(rest, "=:")
+	if i < 0 {
+		return e, 1
+	}
+
+	v := strings.TrimLeftFunc(rest[i+1:], unicode.IsSpace)
+	e.sep = rest[:len(rest)-len(v)]
+	e.col = len(e.indent) + len(e.name) + i
+
+	n := 1
+	switch {
+	case strings.HasPrefix(v, `"""`) && !strings.Contains(v[3:], `"""`):
+		n = spanUntil(lines, `"""`)
+	case strings.HasPrefix(v, "`") && !strings.Contains(v[1:], "`"):
+		n = spanUntil(lines, "`")
+	case strings.HasSuffix(strings.TrimSpace(v), `\`):
+		for n < len(lines) && strings.HasSuffix(strings.TrimSpace(lines[n-1]), `\`) && len(strings.TrimSpace(lines[n])) > 0 {
+			n++
+		}
+	}
+	e.text = strings.Join(lines[:n], d.eol)
+
+	// keep any inline comment, go-ini ends unquoted values at the first comment character
+	e.value = v
+	if !strings.HasPrefix(v, "`") && !strings.HasPrefix(v, `"""`) {
+		if j := strings.IndexAny(v, "#;"); j > -1 {
+			e.value = v[:j]
+		}
+	}
+	e.value = strings.TrimRightFunc(e.value, unicode.IsSpace)
+	e.suffix = v[len(e.value):]
+
+	if len(e.value) > 1 && (e.value[0] == '"' || e.value[0] == '\'') && e.value[len(e.value)-1] == e.value[0] {
+		e.quote = e.value[0]
+	}
+
+	return e, n
+}
+
+// render returns the current data of the ini.File, using the document layout
+func (d *document) render(f *ini.File) []byte {
+	out := make([]string, 0)
+	keys := make(map[string]map[string]bool)
+	last := make(map[string]int)
+
+	for i, ds := range d.sections {
+		last[ds.name] = i
+		if _, ok := keys[ds.name]; !ok {
+			keys[ds.name] = make(map[string]bool)
+		}
+
+		for _, e := range ds.entries {
+			if len(e.key) > 0 {
+				keys[ds.name][e.key] = true
+			}
+		}
+	}
+
+	for i, ds := range d.sections {
+		s, err := f.GetSection(ds.name)
+		if err != nil {
+			// section was deleted
+			continue
+		}
+
+		// new keys are added to the last section of the document with the name, if the section is repeated
+		added := make([]string, 0)
+		if last[ds.name] == i {
+			for _, k := range s.KeyStrings() {
+				if !keys[ds.name][k] && d.changed(s, k) {
+					added = append(added, k)
+				}
+			}
+		}
+		newLines := d.sectionStyle(i).lines(s, added)
+
+		out = append(out, ds.leading...)
+		if len(ds.header) > 0 {
+			out = append(out, ds.header)
+		}
+
+		at := ds.lastKey()
+		if at < 0 {
+			out = append(out, newLines...)
+		}
+
+		cur := s.KeysHash()
+		for j, e := range ds.entries {
+			if len(e.key) < 1 {
+				out = append(out, e.text)
+			} else if v, ok := cur[e.key]; ok {
+				if v == d.values[ds.name][e.key] {
+					out = append(out, e.text)
+				} else {
+					out = append(out, e.indent+e.name+e.sep+formatValue(v, e.quote)+e.suffix)
+				}
+			}
+
+			if j == at {
+				out = append(out, newLines...)
+			}
+		}
+	}
+
+	for _, s := range f.Sections() {
+		if _, ok := keys[s.Name()]; ok {
+			continue
+		}
+
+		// sections from other sources only have the changed keys written, sections created since loading are written in full
+		names := make([]string, 0)
+		for _, k := range s.KeyStrings() {
+			if d.changed(s, k) {
+				names = append(names, k)
+			}
+		}
+
+		if _, ok := d.values[s.Name()]; ok && len(names) < 1 {
+			continue
+		}
+
+		if len(out) > 0 && len(strings.TrimSpace(out[len(out)-1])) > 0 {
+			out = append(out, "")
+		}
+		out = append(out, "["+s.Name()+"]")
+		out = append(out, d.fileStyle(len(d.sections)).lines(s, names)...)
+	}
+
+	data := strings.Join(out, d.eol)
+	if d.final && len(out) > 0 {
+		data += d.eol
+	}
+	return []byte(data)
+}
+
+// changed returns true if the key was added to the section, or has a different value, since the data was loaded
+func (d *document) changed(s *ini.Section, key string) bool {
+	v, ok := d.values[s.Name()][key]
+	return !ok || v != s.Key(key).Value()
+}
+
+// keyStyle is the formatting used to write new keys
+type keyStyle struct {
+	indent  string
+	sep     string
+	aligned bool
+	width   int
+	quote   byte
+}
+
+// lines returns the formatted lines for the named keys of the section
+func (st keyStyle) lines(s *ini.Section, names []string) []string {
+	width := st.width
+	if st.aligned && width < 1 {
+		for _, k := range names {
+			if len(k) > width {
+				width = len(k)
+			}
+		}
+	}
+
+	l := make([]string, 0, len(names))
+	for _, k := range names {
+		pad := ""
+		if st.aligned && width > len(k) {
+			pad = strings.Repeat(" ", width-len(k))
+		}
+
+		n := k
+		if strings.ContainsAny(k, "=:\"") {
+			n = "`" + k + "`"
+		}
+		l = append(l, st.indent+n+pad+st.sep+formatValue(s.Key(k).Value(), st.quote))
+	}
+	return l
+}
+
+// sectionStyle returns the formatting for new keys in the i'th section of the document, based on the existing keys
+// of the section.  The keys are aligned if the existing keys are, and values are quoted if all existing values are
+// quoted.  Sections without keys use the file style of the preceding sections.
+func (d *document) sectionStyle(i int) keyStyle {
+	ds := d.sections[i]
+	at := ds.lastKey()
+	if at < 0 {
+		return d.fileStyle(i)
+	}
+
+	e := ds.entries[at]
+	st := keyStyle{indent: e.indent, sep: e.sep, quote: e.quote}
+	if sep := strings.TrimLeftFunc(e.sep, unicode.IsSpace); len(sep) < len(e.sep) {
+		st.sep = " " + sep
+	}
+
+	lengths := make(map[int]bool)
+	st.aligned = true
+	for _, x := range ds.entries {
+		if len(x.key) < 1 {
+			continue
+		}
+
+		lengths[len(x.key)] = true
+		st.aligned = st.aligned && x.col == e.col
+		if x.quote != st.quote {
+			st.quote = 0
+		}
+	}
+
+	// keys of the same length are lined up without any padding, so that's no indication the keys are aligned
+	st.aligned = st.aligned && len(lengths) > 1
+	if st.aligned {
+		st.width = e.col - len(e.indent) - (len(st.sep) - len(strings.TrimLeftFunc(st.sep, unicode.IsSpace)))
+	}
+	return st
+}
+
+// fileStyle returns the formatting for keys in sections without existing keys, based on the nearest section with
+// keys before the n'th section of the document.  The keys are aligned (like the go-ini output) if there is no such
+// section, or its keys are aligned.
+func (d *document) fileStyle(n int) keyStyle {
+	for i := n - 1; i >= 0; i-- {
+		if d.sections[i].lastKey() > -1 {
+			st := d.sectionStyle(i)
+			st.width = 0
+			return st
+		}
+	}
+	return keyStyle{sep: " = ", aligned: true}
+}
+
+// lastKey returns the index of the last key entry in the section, or -1 if the section has no keys
+func (ds *docSection) lastKey() int {
+	for i := len(ds.entries) - 1; i >= 0; i-- {
+		if len(ds.entries[i].key) > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// trailingComments removes the comment lines at the end of the section, returning them.  These are the comments
+// directly above the next section header, and belong with that section.
+func (ds *docSection) trailingComments() []string {
+	i := len(ds.entries)
+	for i > 0 && len(ds.entries[i-1].key) < 1 && len(strings.TrimSpace(ds.entries[i-1].text)) > 0 {
+		i--
+	}
+
+	c := make([]string, 0)
+	for _, e := range ds.entries[i:] {
+		c = append(c, e.text)
+	}
+	ds.entries = ds.entries[:i]
+	return c
+}
+
+// splitKeyName returns the key name at the start of the line, and the rest of the line following the name
+func splitKeyName(l string) (string, string) {
+	for _, q := range []string{`"""`, "`", `"`} {
+		if strings.HasPrefix(l, q) {
+			if i := strings.Index(l[len(q):], q); i > -1 {
+				return l[len(q) : len(q)+i], l[len(q)+i+len(q):]
+			}
+		}
+	}
+
+	i := strings.IndexAny(l, "=:")
+	if i < 0 {
+		return strings.TrimSpace(l), ""
+	}
+	return strings.TrimSpace(l[:i]), l[len(strings.TrimRightFunc(l[:i], unicode.IsSpace)):]
+}
+
+// spanUntil returns the number of lines up to, and including, the line after the first which contains the string
+func spanUntil(lines []string, s string) int {
+	for i := 1; i < len(lines); i++ {
+		if strings.Contains(lines[i], s) {
+			return i + 1
+		}
+	}
+	return len(lines)
+}
+
+// formatValue returns the value formatted so go-ini will read back the same value, using the quote character if the
+// value doesn't need go-ini's own quoting
+func formatValue(v string, quote byte) string {
+	switch {
+	case strings.ContainsAny(v, "\n`"):
+		return `"""` + v + `"""`
+	case strings.ContainsAny(v, "#;") || strings.HasSuffix(v, `\`) || v != strings.TrimSpace(v) ||
+		(len(v) > 1 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0]):
+		return "`" + v + "`"
+	case quote != 0 && strings.IndexByte(v, quote) < 0:
+		return string(quote) + v + string(quote)
+	}
+	return v
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/go-ini/ini"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+var documentConfig = `# shared settings
+[default]
+region = us-east-1   # closest region
+output=json
+
+; the admin role
+[profile admin]
+role_arn       = arn:aws:iam::123456789012:role/Admin
+source_profile = default
+
+[profile empty]
+
+[profile quoted]
+region = "us-west-2"
+  custom : "value"
+`
+
+func writeDocument(t *testing.T, f *awsConfigFile) string {
+	b := new(bytes.Buffer)
+	if _, err := f.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestDocument_RoundTrip(t *testing.T) {
+	creds, err := ioutil.ReadFile(credFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string]string{
+		"config":      documentConfig,
+		"credentials": string(creds),
+		"crlf":        strings.ReplaceAll(documentConfig, "\n", "\r\n"),
+		"multiline":   "[default]\nlong = \"\"\"line 1\nline 2\"\"\"\ncontinued = one \\\n  two\n",
+		"empty":       "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, err := NewIniConfigProvider([]byte(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if s := writeDocument(t, p.awsConfigFile); s != data {
+				t.Errorf("unexpected output:\n%q\n%q", data, s)
+			}
+		})
+	}
+}
+
+func TestDocument_Edit(t *testing.T) {
+	p, err := NewIniConfigProvider([]byte(documentConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edits := []struct {
+		attr, profile, value string
+	}{
+		{"region", "default", "us-east-2"},
+		{"output", "default", "text"},
+		{"mfa_serial", "admin", "arn:aws:iam::123456789012:mfa/user"},
+		{"region", "empty", "eu-west-1"},
+		{"region", "quoted", "us-west-1"},
+		{"custom", "quoted", "has # comment"},
+		{"output", "quoted", "table"},
+		{"region", "new", "ap-south-1"},
+	}
+
+	for _, e := range edits {
+		if err := p.SetAttribute(e.profile, e.attr, e.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := p.UnsetAttribute("admin", "source_profile"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# shared settings
+[default]
+region = us-east-2   # closest region
+output=text
+
+; the admin role
+[profile admin]
+role_arn       = arn:aws:iam::123456789012:role/Admin
+mfa_serial     = arn:aws:iam::123456789012:mfa/user
+
+[profile empty]
+region = eu-west-1
+
+[profile quoted]
+region = "us-west-1"
+  custom : ` + "`has # comment`" + `
+  output : "table"
+
+[profile new]
+  region : "ap-south-1"
+`
+
+	s := writeDocument(t, p.awsConfigFile)
+	if s != expected {
+		t.Errorf("unexpected output:\n%s", s)
+	}
+
+	// the output must read back as the same data
+	c, err := NewIniConfigProvider([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range edits {
+		if v := c.Section("profile " + e.profile).Key(e.attr).String(); e.profile != DefaultProfileName && v != e.value {
+			t.Errorf("unexpected value for %s.%s: %s", e.profile, e.attr, v)
+		}
+	}
+
+	t.Run("delete section", func(t *testing.T) {
+		p.DeleteSection("profile admin")
+		if s := writeDocument(t, p.awsConfigFile); strings.Contains(s, "admin") || !strings.Contains(s, "output=text\n\n[profile empty]") {
+			t.Errorf("unexpected output:\n%s", s)
+		}
+	})
+}
+
+func TestDocument_UpdateCredentials(t *testing.T) {
+	data, err := ioutil.ReadFile(credFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewIniCredentialProvider(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.UpdateCredentials("token", credentials.Value{AccessKeyID: "newkey", SecretAccessKey: "newsecret", SessionToken: "newtoken"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.UpdateCredentials("other", credentials.Value{AccessKeyID: "AKIANEW", SecretAccessKey: "n3wSecr3T"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := writeDocument(t, p.awsConfigFile)
+	expected := strings.NewReplacer(
+		`"accesskey"`, `"newkey"`,
+		`"secretkey"`, `"newsecret"`,
+		`"sessioncreds"`, `"newtoken"`,
+		"AKIA0THER", "AKIANEW",
+		"0th3rSecr3T", "n3wSecr3T",
+	).Replace(string(data))
+
+	if s != expected {
+		t.Errorf("unexpected output:\n%s", s)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for _, v := range []string{"plain", "has # hash", "semi;colon", `"quoted"`, " spaces ", "back`tick", "multi\nline", `ends\`} {
+		for _, q := range []byte{0, '"', '\''} {
+			f, err := ini.Load([]byte("[s]\nk = " + formatValue(v, q)))
+			if err != nil {
+				t.Errorf("%q: %v", v, err)
+				continue
+			}
+
+			if x := f.Section("s").Key("k").String(); x != v {
+				t.Errorf("value mismatch: %q != %q", x, v)
+			}
+		}
+	}
+}
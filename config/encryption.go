@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"io/ioutil"
+)
+
+// encryptedFileMagic is the header of encrypted ini files.  The header is followed by the scrypt salt, the AES-GCM
+// nonce, and the encrypted ini data.
+var encryptedFileMagic = []byte("AWSCONFIG-ENCRYPTED-1\n")
+
+// encryption is the key and salt used to encrypt the file data when written
+type encryption struct {
+	key  []byte
+	salt []byte
+}
+
+func newEncryption(passphrase []byte) (*encryption, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &encryption{key: key, salt: salt}, nil
+}
+
+// WithPassphrase is an Option for setting the passphrase used to decrypt an encrypted credentials (or config) file.
+// Encrypted files are detected automatically when loaded, and plaintext files are loaded as-is.
+func WithPassphrase(passphrase []byte) Option {
+	return func(o *options) {
+		o.passphrase = passphrase
+	}
+}
+
+// WithKeyFile is an Option for setting the path of a file holding the passphrase used to decrypt an encrypted
+// credentials (or config) file.  Trailing newlines in the file are ignored.  If both WithKeyFile and WithPassphrase are
+// used, the passphrase takes precedence.
+func WithKeyFile(path string) Option {
+	return func(o *options) {
+		o.keyFile = path
+	}
+}
+
+// secret returns the passphrase of the options, reading the key file if required
+func (o *options) secret() ([]byte, error) {
+	if len(o.passphrase) > 0 || len(o.keyFile) < 1 {
+		return o.passphrase, nil
+	}
+
+	b, err := ioutil.ReadFile(o.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(b, "\r\n"), nil
+}
+
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedFileMagic)
+}
+
+// decrypt returns the plaintext of the encrypted data, and keeps the key so the file is re-encrypted when written
+func (f *awsConfigFile) decrypt(data []byte, o *options) ([]byte, error) {
+	passphrase, err := o.secret()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(passphrase) < 1 {
+		return nil, ErrPassphraseRequired
+	}
+
+	salt, err := sealedSalt(encryptedFileMagic, data)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := openWithKey(encryptedFileMagic, key, data)
+	if err != nil {
+		return nil, err
+	}
+
+	f.enc = &encryption{key: key, salt: append([]byte{}, salt...)}
+	f.logger().Debug("decrypted ini data", "path", f.Path)
+	return p, nil
+}
+
+// Encrypted returns true if the data written by WriteTo() and SaveTo() is encrypted
+func (f *awsConfigFile) Encrypted() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.enc != nil
+}
+
+// Encrypt sets the passphrase used to encrypt the data written by WriteTo() and SaveTo(), using a new random salt.
+// This is used to convert a plaintext file to the encrypted format, or change the passphrase of an encrypted file.
+func (f *awsConfigFile) Encrypt(passphrase []byte) error {
+	enc, err := newEncryption(passphrase)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.enc = enc
+	return nil
+}
+
+// Decrypt disables encryption, so the data written by WriteTo() and SaveTo() is plaintext ini data
+func (f *awsConfigFile) Decrypt() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.enc = nil
+}
+
+// EncryptFile converts the plaintext ini file at path to the encrypted format using the passphrase.  The file is
+// replaced atomically.  Encrypting a file which is already encrypted returns ErrPassphraseRequired.
+func EncryptFile(path string, passphrase []byte) error {
+	f, err := load(path, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Encrypt(passphrase); err != nil {
+		return err
+	}
+	return f.saveAtomic(path)
+}
+
+// DecryptFile converts the encrypted ini file at path to plaintext ini data, using the passphrase.  The file is
+// replaced atomically.
+func DecryptFile(path string, passphrase []byte) error {
+	f, err := load(path, nil, WithPassphrase(passphrase))
+	if err != nil {
+		return err
+	}
+
+	f.Decrypt()
+	return f.saveAtomic(path)
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func encryptedCredentials(t *testing.T) (string, func()) {
+	d, err := ioutil.TempDir("", "encryption")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(credFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(d, "credentials")
+	if err := ioutil.WriteFile(fn, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EncryptFile(fn, []byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	return fn, func() { os.RemoveAll(d) }
+}
+
+func TestEncryptFile(t *testing.T) {
+	fn, cleanup := encryptedCredentials(t)
+	defer cleanup()
+
+	t.Run("encrypted", func(t *testing.T) {
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !isEncrypted(data) || bytes.Contains(data, []byte("AKIA0THER")) {
+			t.Error("file data is not encrypted")
+		}
+	})
+
+	t.Run("passphrase", func(t *testing.T) {
+		p, err := NewIniCredentialProvider(fn, WithPassphrase([]byte("passphrase")))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		v, err := p.Credentials("other")
+		if err != nil || v.AccessKeyID != "AKIA0THER" {
+			t.Errorf("data mismatch: %+v, %v", v, err)
+		}
+
+		if !p.Encrypted() {
+			t.Error("provider not marked as encrypted")
+		}
+	})
+
+	t.Run("key file", func(t *testing.T) {
+		kf := filepath.Join(filepath.Dir(fn), "key")
+		if err := ioutil.WriteFile(kf, []byte("passphrase\n"), 0600); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if _, err := NewIniCredentialProvider(fn, WithKeyFile(kf)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("no passphrase", func(t *testing.T) {
+		if _, err := NewIniCredentialProvider(fn); !errors.Is(err, ErrPassphraseRequired) {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if err := EncryptFile(fn, []byte("passphrase")); !errors.Is(err, ErrPassphraseRequired) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("bad passphrase", func(t *testing.T) {
+		if _, err := NewIniCredentialProvider(fn, WithPassphrase([]byte("wrong"))); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		p, err := NewIniCredentialProvider(fn, WithPassphrase([]byte("passphrase")))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if err := p.UpdateCredentials("other", map[string]string{}); err == nil {
+			t.Error("did not receive expected error")
+		}
+
+		if err := p.UpdateCredentials("empty", credentials.Value{AccessKeyID: "AKIAUPDATED", SecretAccessKey: "s"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if err := p.SaveTo(fn); err != nil {
+			t.Error(err)
+			return
+		}
+
+		p, err = NewIniCredentialProvider(fn, WithPassphrase([]byte("passphrase")))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if v, err := p.Credentials("empty"); err != nil || v.AccessKeyID != "AKIAUPDATED" {
+			t.Errorf("data mismatch: %+v, %v", v, err)
+		}
+	})
+
+	t.Run("decrypt", func(t *testing.T) {
+		if err := DecryptFile(fn, []byte("passphrase")); err != nil {
+			t.Error(err)
+			return
+		}
+
+		p, err := NewIniCredentialProvider(fn)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if v, err := p.Credentials("empty"); err != nil || v.AccessKeyID != "AKIAUPDATED" || p.Encrypted() {
+			t.Errorf("data mismatch: %+v, %v", v, err)
+		}
+	})
+}
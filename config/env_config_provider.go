@@ -1,8 +1,6 @@
 package config
 
 import (
-	"fmt"
-	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -10,17 +8,56 @@ import (
 )
 
 // EnvConfigProvider enables the lookup of AWS configuration from environment variables
-type EnvConfigProvider uint8
+type EnvConfigProvider struct {
+	prefix   string
+	envNames map[string]string
+	policy   *ProfilePolicy
+	env      Environment
+	log      Logger
+}
 
 // NewEnvConfigProvider creates an EnvConfigProvider with the default configuration
-func NewEnvConfigProvider() *EnvConfigProvider {
-	return new(EnvConfigProvider)
+func NewEnvConfigProvider(opts ...Option) *EnvConfigProvider {
+	o := newOptions(opts)
+	return &EnvConfigProvider{envNames: make(map[string]string), policy: o.policy(), env: o.env, log: o.log}
+}
+
+// WithPrefix is a fluent method for setting a prefix used to build additional environment variable names.  The upper-cased
+// attribute name is appended to the prefix (for example, a prefix of "MYTOOL_" will look up role_arn using MYTOOL_ROLE_ARN),
+// and that variable takes precedence over the default variable names for the attribute
+func (p *EnvConfigProvider) WithPrefix(prefix string) *EnvConfigProvider {
+	p.prefix = prefix
+	return p
+}
+
+// WithEnvNames is a fluent method for overriding the environment variable names used to look up attributes.  The map
+// keys are the INI attribute names (or "profile" for the profile name), and the values are a comma-separated list of
+// environment variable names to check, in order.  Overridden attributes do not check the prefixed or default names.
+func (p *EnvConfigProvider) WithEnvNames(names map[string]string) *EnvConfigProvider {
+	if p.envNames == nil {
+		p.envNames = make(map[string]string)
+	}
+
+	for k, v := range names {
+		p.envNames[k] = v
+	}
+	return p
+}
+
+// WithProfilePolicy is a fluent method for setting the ProfilePolicy used to look up the profile name in the
+// environment.  The policy is not used if the profile env var name is overridden using WithEnvNames()
+func (p *EnvConfigProvider) WithProfilePolicy(policy *ProfilePolicy) *EnvConfigProvider {
+	p.policy = policy
+	return p
 }
 
 // Config will return the configuration attributes found in the environment variables.  The profile argument to this
-// call is ignored, and only used to set the Profile attribute of the returned AwsConfig object.
+// call is ignored, and only used to set the Profile attribute of the returned AwsConfig object.  If the profile
+// argument is not provided, the profile name is found using the prefixed env var (if a prefix is set), or the env vars
+// of the provider's ProfilePolicy.  Unlike other providers, the policy default profile name is not used.
 func (p *EnvConfigProvider) Config(profile ...string) (*AwsConfig, error) {
-	c := AwsConfig{}
+	c := AwsConfig{rawAttributes: make(map[string]string)}
+	log := loggerOrNop(p.log)
 
 	v := reflect.ValueOf(&c)
 	t := reflect.TypeOf(c)
@@ -28,7 +65,16 @@ func (p *EnvConfigProvider) Config(profile ...string) (*AwsConfig, error) {
 		tField := t.Field(i)
 		vField := v.Elem().Field(i)
 
-		e := lookupEnvTag(tField.Tag.Get("env"))
+		if !vField.CanSet() {
+			continue
+		}
+
+		attr := attrName(tField)
+		e, ok := lookupEnv(p.env, p.names(attr, tField.Tag.Get("env")))
+		if ok && tField.Tag.Get("ini") != "" {
+			c.rawAttributes[attr] = e
+			log.Debug("attribute found in environment", "attribute", attr)
+		}
 
 		switch tField.Type.Kind() {
 		case reflect.String:
@@ -42,7 +88,12 @@ func (p *EnvConfigProvider) Config(profile ...string) (*AwsConfig, error) {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			i, err := strconv.ParseInt(e, 0, 64)
 			if err != nil {
-				i = 0
+				// support duration strings (like the CREDENTIALS_DURATION env var), converted to seconds
+				d, err := time.ParseDuration(e)
+				if err != nil {
+					d = 0
+				}
+				i = int64(d.Seconds())
 			}
 			vField.SetInt(i)
 			//case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -61,6 +112,10 @@ func (p *EnvConfigProvider) Config(profile ...string) (*AwsConfig, error) {
 		}
 	}
 
+	if _, ok := p.envNames[profileAttribute]; !ok && len(c.Profile) < 1 {
+		c.Profile, _ = policyOrDefault(p.policy).FromEnv()
+	}
+
 	if profile != nil && len(profile) > 0 {
 		c.Profile = profile[0]
 	}
@@ -68,26 +123,91 @@ func (p *EnvConfigProvider) Config(profile ...string) (*AwsConfig, error) {
 	return &c, nil
 }
 
-// ListProfiles is not supported for EnvConfigProviders, returns an empty array
+// ListProfiles will return the profile name found in the profile environment variable (AWS_PROFILE, unless overridden)
+// as a single element array, or an empty array if the variable is not set.  If the roles arg is true, the profile name
+// is only returned if the role_arn attribute is also found in the environment.
 func (p *EnvConfigProvider) ListProfiles(roles bool) []string {
-	return []string{}
+	c, _ := p.Config()
+
+	if len(c.Profile) < 1 || (roles && len(c.RoleArn) < 1) {
+		return []string{}
+	}
+	return []string{c.Profile}
 }
 
-func lookupEnvTag(tag string) string {
-	if len(tag) > 0 {
-		for _, s := range strings.Split(tag, ",") {
-			if v, ok := os.LookupEnv(s); ok {
-				if s == "CREDENTIALS_DURATION" {
-					d, err := time.ParseDuration(v)
-					if err != nil {
-						continue
-					}
-					v = fmt.Sprintf("%d", int64(d.Seconds()))
-				}
+// QueryProfiles will return the profile found in the environment as a single element array if it matches the provided
+// query, otherwise an empty array is returned.  The Source of the returned entry is "env".
+func (p *EnvConfigProvider) QueryProfiles(q *ProfileQuery) []ProfileEntry {
+	c, _ := p.Config()
+
+	if len(c.Profile) < 1 || !q.Matches(c.Profile, c.rawAttributes) {
+		return []ProfileEntry{}
+	}
+	return []ProfileEntry{{Name: c.Profile, Kind: profileKind(c.rawAttributes), Source: "env"}}
+}
 
-				return v
+// names returns the ordered list of environment variable names to check for the given attribute
+func (p *EnvConfigProvider) names(attr, tag string) []string {
+	if o, ok := p.envNames[attr]; ok {
+		return splitNames(o)
+	}
+
+	n := make([]string, 0)
+	if len(p.prefix) > 0 {
+		n = append(n, p.prefix+strings.ToUpper(attr))
+	}
+
+	// the profile env vars are looked up using the ProfilePolicy
+	if attr == profileAttribute {
+		return n
+	}
+	return append(n, splitNames(tag)...)
+}
+
+// exportNames returns the env var names for the attribute for use by the EnvExporter, where the first name is the one
+// to export.  Unlike names(), the names for the profile attribute include the ProfilePolicy env vars.
+func (p *EnvConfigProvider) exportNames(attr, tag string) []string {
+	n := p.names(attr, tag)
+
+	if _, ok := p.envNames[attr]; !ok && attr == profileAttribute {
+		n = append(n, splitNames(tag)...)
+		for _, e := range policyOrDefault(p.policy).envVars {
+			if !stringInSlice(e, n) {
+				n = append(n, e)
 			}
 		}
 	}
-	return ""
+	return n
+}
+
+// attrName returns the INI attribute name for the field, or the lower-cased field name if there is no ini tag
+func attrName(f reflect.StructField) string {
+	if n := f.Tag.Get("ini"); len(n) > 0 {
+		return n
+	}
+	return strings.ToLower(f.Name)
+}
+
+func splitNames(s string) []string {
+	n := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			n = append(n, v)
+		}
+	}
+	return n
+}
+
+// lookupEnv returns the value of the first variable found in the environment (or the process environment if env is nil)
+func lookupEnv(env Environment, names []string) (string, bool) {
+	if env == nil {
+		env = OsEnvironment{}
+	}
+
+	for _, s := range names {
+		if v, ok := env.LookupEnv(s); ok {
+			return v, true
+		}
+	}
+	return "", false
 }
@@ -94,7 +94,99 @@ func TestEnvConfigProvider_Config(t *testing.T) {
 	})
 }
 
+func TestEnvConfigProvider_WithPrefix(t *testing.T) {
+	c := NewEnvConfigProvider().WithPrefix("MYTOOL_")
+
+	t.Run("prefixed vars", func(t *testing.T) {
+		os.Setenv("MYTOOL_ROLE_ARN", "arn:aws:iam::123456789012:role/Admin")
+		os.Setenv("MYTOOL_MFA_SERIAL", "GAHT12345678")
+		os.Setenv("MFA_SERIAL", "not-me")
+		defer func() {
+			os.Unsetenv("MYTOOL_ROLE_ARN")
+			os.Unsetenv("MYTOOL_MFA_SERIAL")
+			os.Unsetenv("MFA_SERIAL")
+		}()
+
+		v, err := c.Config()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if v.RoleArn != "arn:aws:iam::123456789012:role/Admin" || v.MfaSerial != "GAHT12345678" {
+			t.Error("data mismatch")
+		}
+
+		if v.Get("role_arn") != v.RoleArn {
+			t.Error("raw attribute mismatch")
+		}
+	})
+
+	t.Run("default fallback", func(t *testing.T) {
+		os.Setenv("AWS_REGION", "us-east-2")
+		defer os.Unsetenv("AWS_REGION")
+
+		v, err := c.Config()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if v.Region != "us-east-2" {
+			t.Error("bad region")
+		}
+	})
+}
+
+func TestEnvConfigProvider_WithEnvNames(t *testing.T) {
+	c := NewEnvConfigProvider().WithEnvNames(map[string]string{
+		"profile":          "MYTOOL_PROFILE",
+		"duration_seconds": "MYTOOL_DURATION",
+	})
+
+	os.Setenv("MYTOOL_PROFILE", "tool")
+	os.Setenv("AWS_PROFILE", "not-me")
+	os.Setenv("MYTOOL_DURATION", "1h")
+	defer func() {
+		os.Unsetenv("MYTOOL_PROFILE")
+		os.Unsetenv("AWS_PROFILE")
+		os.Unsetenv("MYTOOL_DURATION")
+	}()
+
+	v, err := c.Config()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if v.Profile != "tool" || v.DurationSeconds != 3600 {
+		t.Errorf("data mismatch: %+v", v)
+	}
+
+	if p := c.ListProfiles(false); len(p) != 1 || p[0] != "tool" {
+		t.Error("bad profile list")
+	}
+}
+
 func TestEnvConfigProvider_ListProfiles(t *testing.T) {
+	t.Run("profile env", func(t *testing.T) {
+		os.Setenv("AWS_PROFILE", "pfile")
+		defer os.Unsetenv("AWS_PROFILE")
+
+		p := cfg.ListProfiles(false)
+		if len(p) != 1 || p[0] != "pfile" {
+			t.Error("did not receive expected profile")
+		}
+
+		if len(cfg.ListProfiles(true)) > 0 {
+			t.Error("unexpectedly received role profile")
+		}
+
+		if e := cfg.QueryProfiles(&ProfileQuery{Name: "p*"}); len(e) != 1 || e[0].Source != "env" {
+			t.Error("did not receive expected profile entry")
+		}
+	})
+
 	t.Run("arg true", func(t *testing.T) {
 		p := cfg.ListProfiles(true)
 		if len(p) > 0 {
@@ -5,18 +5,48 @@ import (
 )
 
 // EnvCredentialProvider enables the lookup of AWS credentials from environment variables
-type EnvCredentialProvider uint8
+type EnvCredentialProvider struct {
+	env Environment
+	log Logger
+}
 
 // NewEnvCredentialProvider initializes a default EnvCredentialProvider
-func NewEnvCredentialProvider() *EnvCredentialProvider {
-	return new(EnvCredentialProvider)
+func NewEnvCredentialProvider(opts ...Option) *EnvCredentialProvider {
+	o := newOptions(opts)
+	return &EnvCredentialProvider{env: o.env, log: o.log}
 }
 
 // Credentials will retrieve AWS credentials from the SDK supported environment variables.
 // For Access Keys, these are ... AWS_ACCESS_KEY_ID and AWS_ACCESS_KEY
 // for Secret Keys, these are ... AWS_SECRET_ACCESS_KEY and AWS_SECRET_KEY
 // for Session Tokens, this is ... AWS_SESSION_TOKEN
+// The errors returned for missing values are an IncompleteCredentialsError wrapping the same error as the AWS SDK
+// credentials.EnvProvider
 func (p *EnvCredentialProvider) Credentials(profile ...string) (credentials.Value, error) {
-	ec := credentials.NewEnvCredentials()
-	return ec.Get()
+	v := credentials.Value{ProviderName: credentials.EnvProviderName}
+
+	v.AccessKeyID, _ = lookupEnv(p.env, []string{"AWS_ACCESS_KEY_ID"})
+	if len(v.AccessKeyID) < 1 {
+		v.AccessKeyID, _ = lookupEnv(p.env, []string{"AWS_ACCESS_KEY"})
+	}
+
+	v.SecretAccessKey, _ = lookupEnv(p.env, []string{"AWS_SECRET_ACCESS_KEY"})
+	if len(v.SecretAccessKey) < 1 {
+		v.SecretAccessKey, _ = lookupEnv(p.env, []string{"AWS_SECRET_KEY"})
+	}
+
+	log := loggerOrNop(p.log)
+	if len(v.AccessKeyID) < 1 {
+		log.Debug("access key not found in environment")
+		return v, &IncompleteCredentialsError{Err: credentials.ErrAccessKeyIDNotFound}
+	}
+
+	if len(v.SecretAccessKey) < 1 {
+		log.Debug("secret key not found in environment")
+		return v, &IncompleteCredentialsError{Err: credentials.ErrSecretAccessKeyNotFound}
+	}
+
+	v.SessionToken, _ = lookupEnv(p.env, []string{"AWS_SESSION_TOKEN"})
+	log.Debug("credentials found in environment", "session_token", len(v.SessionToken) > 0)
+	return v, nil
 }
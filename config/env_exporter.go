@@ -0,0 +1,194 @@
+package config
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ShellFormat is the syntax used when exporting environment variables
+type ShellFormat string
+
+const (
+	// ShellBash is the export syntax for bash, zsh, and other POSIX shells
+	ShellBash ShellFormat = "bash"
+	// ShellZsh is an alias for ShellBash
+	ShellZsh ShellFormat = "zsh"
+	// ShellFish is the export syntax for the fish shell
+	ShellFish ShellFormat = "fish"
+	// ShellPowerShell is the export syntax for PowerShell
+	ShellPowerShell ShellFormat = "powershell"
+	// ShellDotenv is the syntax for dotenv (.env) files.  Unset variables are written with an empty value
+	ShellDotenv ShellFormat = "dotenv"
+)
+
+// EnvExporter writes AWS configuration and credentials as environment variable assignments which can be evaluated by
+// a shell.  This is the inverse of the EnvConfigProvider and EnvCredentialProvider types.
+type EnvExporter struct {
+	format   ShellFormat
+	unset    bool
+	provider *EnvConfigProvider
+}
+
+// NewEnvExporter creates an EnvExporter which writes assignments using the syntax of the given format
+func NewEnvExporter(format ShellFormat) *EnvExporter {
+	return &EnvExporter{format: format, provider: NewEnvConfigProvider()}
+}
+
+// WithUnset is a fluent method for enabling (or disabling) the output of commands to unset any environment variables
+// which could conflict with the exported values (alternate names for an attribute, or attributes which are not set)
+func (e *EnvExporter) WithUnset(b bool) *EnvExporter {
+	e.unset = b
+	return e
+}
+
+// WithEnvConfigProvider is a fluent method for setting the EnvConfigProvider used to determine the environment variable
+// names of the configuration attributes.  This allows the exported names to honor the prefix and name overrides of the
+// provider.  The first name for an attribute is exported, any other names are considered conflicting.
+func (e *EnvExporter) WithEnvConfigProvider(p *EnvConfigProvider) *EnvExporter {
+	e.provider = p
+	return e
+}
+
+// Export writes the environment variable assignments for the non-empty attributes of the config and credentials to w.
+// Either of config or creds may be nil.
+func (e *EnvExporter) Export(w io.Writer, config *AwsConfig, creds *credentials.Value) error {
+	set := make(map[string]string)
+	known := make(map[string]string)
+
+	if config == nil {
+		config = new(AwsConfig)
+	}
+	e.collect(config, e.provider, set, known)
+
+	c := new(awsCredentials)
+	if creds != nil {
+		c.AccessKey = creds.AccessKeyID
+		c.SecretKey = creds.SecretAccessKey
+		c.SessionToken = creds.SessionToken
+	}
+	e.collect(c, nil, set, known)
+
+	// AWS_REGION is used by the SDKs, AWS_DEFAULT_REGION is used by the CLI
+	if v, ok := set["AWS_REGION"]; ok {
+		set["AWS_DEFAULT_REGION"] = v
+	}
+
+	if e.unset {
+		for _, k := range sortedKeys(known) {
+			if _, ok := set[k]; !ok {
+				if err := e.write(w, k, "", true); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, k := range sortedKeys(set) {
+		if err := e.write(w, k, set[k], false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collect gathers the env var names and values for the exported struct fields with an env var name, either from the
+// env tag, or the EnvConfigProvider if not nil.  The set map is
+// populated with the name and value of non-empty fields, and the known map is populated with all env var names
+func (e *EnvExporter) collect(obj interface{}, p *EnvConfigProvider, set, known map[string]string) {
+	v := reflect.ValueOf(obj).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		tField := t.Field(i)
+		if len(tField.PkgPath) > 0 {
+			// unexported field
+			continue
+		}
+
+		tag := tField.Tag.Get("env")
+		names := splitNames(tag)
+		if p != nil {
+			names = p.exportNames(attrName(tField), tag)
+		}
+
+		for _, n := range names {
+			known[n] = ""
+		}
+
+		val := fmt.Sprint(v.Field(i).Interface())
+		if len(names) > 0 && len(val) > 0 && val != "0" {
+			set[names[0]] = val
+		}
+	}
+}
+
+func (e *EnvExporter) write(w io.Writer, name, value string, unset bool) error {
+	var s string
+
+	switch e.format {
+	case ShellBash, ShellZsh:
+		if unset {
+			s = fmt.Sprintf("unset %s", name)
+		} else {
+			s = fmt.Sprintf("export %s=%s", name, quotePosix(value))
+		}
+	case ShellFish:
+		if unset {
+			s = fmt.Sprintf("set -e %s", name)
+		} else {
+			s = fmt.Sprintf("set -gx %s %s", name, quoteFish(value))
+		}
+	case ShellPowerShell:
+		if unset {
+			s = fmt.Sprintf("Remove-Item Env:%s -ErrorAction SilentlyContinue", name)
+		} else {
+			s = fmt.Sprintf("$Env:%s = %s", name, quotePowerShell(value))
+		}
+	case ShellDotenv:
+		if unset {
+			s = fmt.Sprintf("%s=", name)
+		} else {
+			s = fmt.Sprintf("%s=%s", name, quoteDotenv(value))
+		}
+	default:
+		return fmt.Errorf("unsupported shell format '%s'", e.format)
+	}
+
+	_, err := fmt.Fprintln(w, s)
+	return err
+}
+
+// single quoted strings can't contain a single quote, so close the quote, add an escaped quote, and re-open the quote
+func quotePosix(s string) string {
+	return fmt.Sprintf("'%s'", strings.Replace(s, `'`, `'\''`, -1))
+}
+
+// fish allows backslash escapes for backslash and single quote in single quoted strings
+func quoteFish(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return fmt.Sprintf("'%s'", r.Replace(s))
+}
+
+// PowerShell single quoted strings escape a single quote by doubling it
+func quotePowerShell(s string) string {
+	return fmt.Sprintf("'%s'", strings.Replace(s, `'`, `''`, -1))
+}
+
+// dotenv double quoted strings support backslash escapes, and variable expansion which needs to be escaped
+func quoteDotenv(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, `$`, `\$`)
+	return fmt.Sprintf(`"%s"`, r.Replace(s))
+}
+
+func sortedKeys(m map[string]string) []string {
+	k := make([]string, 0, len(m))
+	for v := range m {
+		k = append(k, v)
+	}
+	sort.Strings(k)
+	return k
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"strings"
+	"testing"
+)
+
+func TestEnvExporter_Export(t *testing.T) {
+	c := &AwsConfig{Profile: "p", Region: "us-east-2", DurationSeconds: 3600, ExternalId: "it's"}
+	v := &credentials.Value{AccessKeyID: "AKIAMOCK", SecretAccessKey: `se"cr$t\`}
+
+	t.Run("bash", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		if err := NewEnvExporter(ShellBash).Export(b, c, v); err != nil {
+			t.Error(err)
+			return
+		}
+
+		s := b.String()
+		for _, x := range []string{
+			"export AWS_REGION='us-east-2'\n",
+			"export AWS_DEFAULT_REGION='us-east-2'\n",
+			"export DURATION_SECONDS='3600'\n",
+			`export EXTERNAL_ID='it'\''s'` + "\n",
+			"export AWS_ACCESS_KEY_ID='AKIAMOCK'\n",
+			"export AWS_PROFILE='p'\n",
+		} {
+			if !strings.Contains(s, x) {
+				t.Errorf("missing '%s' in output:\n%s", x, s)
+			}
+		}
+
+		if strings.Contains(s, "unset") || strings.Contains(s, "AWS_SESSION_TOKEN") {
+			t.Errorf("unexpected output:\n%s", s)
+		}
+	})
+
+	t.Run("bash unset", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		if err := NewEnvExporter(ShellZsh).WithUnset(true).Export(b, c, v); err != nil {
+			t.Error(err)
+			return
+		}
+
+		s := b.String()
+		for _, x := range []string{"unset AWS_SESSION_TOKEN\n", "unset AWS_SECRET_KEY\n", "unset CREDENTIALS_DURATION\n",
+			"unset AWS_DEFAULT_PROFILE\n"} {
+			if !strings.Contains(s, x) {
+				t.Errorf("missing '%s' in output:\n%s", x, s)
+			}
+		}
+
+		if strings.Contains(s, "unset AWS_REGION") || strings.Contains(s, "unset AWS_DEFAULT_REGION") {
+			t.Errorf("unexpected output:\n%s", s)
+		}
+	})
+
+	t.Run("fish", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		if err := NewEnvExporter(ShellFish).WithUnset(true).Export(b, c, v); err != nil {
+			t.Error(err)
+			return
+		}
+
+		s := b.String()
+		if !strings.Contains(s, `set -gx EXTERNAL_ID 'it\'s'`) || !strings.Contains(s, "set -e AWS_SESSION_TOKEN") {
+			t.Errorf("unexpected output:\n%s", s)
+		}
+	})
+
+	t.Run("powershell", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		if err := NewEnvExporter(ShellPowerShell).WithUnset(true).Export(b, c, v); err != nil {
+			t.Error(err)
+			return
+		}
+
+		s := b.String()
+		if !strings.Contains(s, `$Env:EXTERNAL_ID = 'it''s'`) ||
+			!strings.Contains(s, "Remove-Item Env:AWS_SESSION_TOKEN -ErrorAction SilentlyContinue") {
+			t.Errorf("unexpected output:\n%s", s)
+		}
+	})
+
+	t.Run("dotenv", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		if err := NewEnvExporter(ShellDotenv).Export(b, c, v); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if s := b.String(); !strings.Contains(s, `AWS_SECRET_ACCESS_KEY="se\"cr\$t\\"`) {
+			t.Errorf("unexpected output:\n%s", s)
+		}
+	})
+
+	t.Run("env provider names", func(t *testing.T) {
+		p := NewEnvConfigProvider().WithPrefix("MYTOOL_")
+		b := new(bytes.Buffer)
+		if err := NewEnvExporter(ShellBash).WithEnvConfigProvider(p).Export(b, &AwsConfig{RoleArn: "arn"}, nil); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if s := b.String(); s != "export MYTOOL_ROLE_ARN='arn'\n" {
+			t.Errorf("unexpected output:\n%s", s)
+		}
+	})
+
+	t.Run("bad format", func(t *testing.T) {
+		if err := NewEnvExporter("csh").Export(new(bytes.Buffer), c, nil); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Environment is an interface defining the contract for conforming types to provide environment variable lookups.
+// Providers and resolvers use an Environment instead of reading the process environment directly, so callers are able
+// to resolve configuration for different environments concurrently, without modifying process-wide state.
+type Environment interface {
+	LookupEnv(key string) (string, bool)
+}
+
+// OsEnvironment is the Environment backed by the process environment variables
+type OsEnvironment struct{}
+
+// LookupEnv returns the value of the process environment variable, and a boolean indicating if the variable is set
+func (e OsEnvironment) LookupEnv(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// MapEnvironment is an Environment backed by a map of variable names to values
+type MapEnvironment map[string]string
+
+// LookupEnv returns the value of the variable in the map, and a boolean indicating if the variable is set
+func (e MapEnvironment) LookupEnv(key string) (string, bool) {
+	v, ok := e[key]
+	return v, ok
+}
+
+// Option is a function which configures the optional settings of the provider and resolver constructors
+type Option func(o *options)
+
+type options struct {
+	env        Environment
+	log        Logger
+	passphrase []byte
+	keyFile    string
+	keyCreated bool
+	includes   bool
+}
+
+// WithEnvironment is an Option for setting the Environment used by a provider or resolver to look up environment
+// variables, including the profile name and the location of the config and credentials files.  If not set, the
+// OsEnvironment is used.
+func WithEnvironment(env Environment) Option {
+	return func(o *options) {
+		o.env = env
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{env: OsEnvironment{}, log: nopLogger{}}
+	for _, f := range opts {
+		f(o)
+	}
+
+	// options explicitly set to nil use the defaults
+	if o.env == nil {
+		o.env = OsEnvironment{}
+	}
+	o.log = loggerOrNop(o.log)
+	return o
+}
+
+// policy returns the ProfilePolicy for the options Environment
+func (o *options) policy() *ProfilePolicy {
+	if _, ok := o.env.(OsEnvironment); ok {
+		return nil
+	}
+	return NewProfilePolicy().WithEnvironment(o.env)
+}
+
+// sharedFilename returns the value of the environment variable if set, otherwise the path of the named file in the
+// .aws directory of the home directory.  The home directory is found using the environment, falling back to the
+// AWS SDK default location.
+func (o *options) sharedFilename(envVar, name string, def func() string) string {
+	if v, ok := o.env.LookupEnv(envVar); ok {
+		return v
+	}
+
+	homeVar := "HOME"
+	if runtime.GOOS == "windows" {
+		homeVar = "USERPROFILE"
+	}
+
+	if h, ok := o.env.LookupEnv(homeVar); ok && len(h) > 0 {
+		return filepath.Join(h, ".aws", name)
+	}
+	return def()
+}
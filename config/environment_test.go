@@ -0,0 +1,166 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestOptions_SharedFilename(t *testing.T) {
+	def := func() string { return "default" }
+
+	t.Run("env var", func(t *testing.T) {
+		o := newOptions([]Option{WithEnvironment(MapEnvironment{ConfigFileEnvVar: ConfFileName, "HOME": "/home/x"})})
+		if o.sharedFilename(ConfigFileEnvVar, "config", def) != ConfFileName {
+			t.Error("env var not used")
+		}
+	})
+
+	t.Run("home", func(t *testing.T) {
+		o := newOptions([]Option{WithEnvironment(MapEnvironment{"HOME": "/home/x", "USERPROFILE": "/home/x"})})
+		if o.sharedFilename(ConfigFileEnvVar, "config", def) != filepath.Join("/home/x", ".aws", "config") {
+			t.Error("home dir not used")
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		o := newOptions([]Option{WithEnvironment(MapEnvironment{})})
+		if o.sharedFilename(ConfigFileEnvVar, "config", def) != "default" {
+			t.Error("default not used")
+		}
+	})
+}
+
+func TestWithEnvironment(t *testing.T) {
+	t.Run("ini config", func(t *testing.T) {
+		env := MapEnvironment{ConfigFileEnvVar: ConfFileName, ProfileEnvVar: "other"}
+		p, err := NewIniConfigProvider(nil, WithEnvironment(env))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer p.Close()
+
+		if p.Path != ConfFileName {
+			t.Error("config file env var not used")
+			return
+		}
+
+		c, err := p.Config()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Profile != "other" || c.Region != "us-west-1" {
+			t.Error("data mismatch")
+		}
+	})
+
+	t.Run("ini credentials", func(t *testing.T) {
+		env := MapEnvironment{CredentialsFileEnvVar: credFileName, ProfileEnvVar: "other"}
+		p, err := NewIniCredentialProvider(nil, WithEnvironment(env))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer p.Close()
+
+		if p.Path != credFileName {
+			t.Error("credentials file env var not used")
+			return
+		}
+
+		if _, err := p.Credentials(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("resolver", func(t *testing.T) {
+		env := MapEnvironment{ConfigFileEnvVar: ConfFileName, ProfileEnvVar: "mfa"}
+		r, err := NewAwsConfigResolver(nil, WithEnvironment(env))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		c, err := r.Resolve()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Profile != "mfa" || c.SourceProfile != DefaultProfileName {
+			t.Error("data mismatch")
+		}
+	})
+
+	t.Run("env config", func(t *testing.T) {
+		env := MapEnvironment{ProfileEnvVar: "pfile", "AWS_REGION": "eu-west-1", "DURATION_SECONDS": "900"}
+		c, err := NewEnvConfigProvider(WithEnvironment(env)).Config()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Profile != "pfile" || c.Region != "eu-west-1" || c.DurationSeconds != 900 {
+			t.Error("data mismatch")
+		}
+	})
+
+	t.Run("env credentials", func(t *testing.T) {
+		env := MapEnvironment{"AWS_ACCESS_KEY": "mykey", "AWS_SECRET_ACCESS_KEY": "mysecret", "AWS_SESSION_TOKEN": "mytoken"}
+		c, err := NewEnvCredentialProvider(WithEnvironment(env)).Credentials()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.AccessKeyID != "mykey" || c.SecretAccessKey != "mysecret" || c.SessionToken != "mytoken" {
+			t.Error("data mismatch")
+		}
+
+		if _, err := NewEnvCredentialProvider(WithEnvironment(MapEnvironment{})).Credentials(); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("process env untouched", func(t *testing.T) {
+		os.Setenv("AWS_REGION", "us-east-1")
+		defer os.Unsetenv("AWS_REGION")
+
+		c, err := NewEnvConfigProvider(WithEnvironment(MapEnvironment{})).Config()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(c.Region) > 0 {
+			t.Error("process environment used")
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		regions := []string{"us-east-1", "us-east-2", "us-west-1", "us-west-2"}
+		errs := make(chan string, len(regions))
+
+		var wg sync.WaitGroup
+		for _, r := range regions {
+			wg.Add(1)
+			go func(r string) {
+				defer wg.Done()
+				c, err := NewEnvConfigProvider(WithEnvironment(MapEnvironment{"AWS_REGION": r})).Config()
+				if err != nil || c.Region != r {
+					errs <- r
+				}
+			}(r)
+		}
+		wg.Wait()
+		close(errs)
+
+		for r := range errs {
+			t.Errorf("region mismatch for %s", r)
+		}
+	})
+}
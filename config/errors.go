@@ -0,0 +1,216 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/go-ini/ini"
+	"strings"
+)
+
+// Sentinel errors for the failure conditions of the loader and providers.  The typed errors returned by the package
+// match these values using errors.Is(), so callers are not required to inspect error strings.  For example:
+//
+//	if _, err := p.Config("admin"); errors.Is(err, ErrProfileNotFound) {
+//		...
+//	}
+var (
+	// ErrProfileNotFound indicates the requested profile does not exist in the source
+	ErrProfileNotFound = errors.New("profile not found")
+	// ErrIncompleteCredentials indicates the access key and/or secret key of a credential is missing
+	ErrIncompleteCredentials = errors.New("incomplete credentials, missing access key and/or secret key")
+	// ErrUnsupportedScheme indicates the url scheme of a source is not supported by the loader
+	ErrUnsupportedScheme = errors.New("url scheme not supported")
+	// ErrSourceProfileCycle indicates the source_profile attributes of a set of profiles reference each other
+	ErrSourceProfileCycle = errors.New("source_profile cycle")
+	// ErrInheritCycle indicates the x_inherit attributes of a set of profiles reference each other
+	ErrInheritCycle = errors.New("x_inherit cycle")
+	// ErrRoleProfile indicates credentials were requested for a profile which assumes a role
+	ErrRoleProfile = errors.New("profile assumes a role, credentials must be obtained from AWS STS")
+	// ErrSecretNotFound indicates the requested secret does not exist in a SecretStore
+	ErrSecretNotFound = errors.New("secret not found")
+	// ErrPassphraseRequired indicates the source is encrypted, and no passphrase or key file was provided
+	ErrPassphraseRequired = errors.New("source is encrypted, passphrase or key file required")
+)
+
+// ProfileNotFoundError is returned when the named profile is not found in a provider
+type ProfileNotFoundError struct {
+	Profile string
+}
+
+func (e *ProfileNotFoundError) Error() string {
+	return fmt.Sprintf("profile '%s' not found", e.Profile)
+}
+
+// Is returns true if the target is ErrProfileNotFound
+func (e *ProfileNotFoundError) Is(target error) bool {
+	return target == ErrProfileNotFound
+}
+
+// IncompleteCredentialsError is returned when the credentials for a profile do not include both the access key and
+// secret key.  If the provider returned a more specific error (like the AWS SDK errors returned by the
+// EnvCredentialProvider), it is available using errors.Unwrap().
+type IncompleteCredentialsError struct {
+	Profile string
+	Err     error
+}
+
+func (e *IncompleteCredentialsError) Error() string {
+	msg := ErrIncompleteCredentials.Error()
+	if len(e.Profile) > 0 {
+		msg = fmt.Sprintf("profile '%s': %s", e.Profile, msg)
+	}
+
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// Is returns true if the target is ErrIncompleteCredentials
+func (e *IncompleteCredentialsError) Is(target error) bool {
+	return target == ErrIncompleteCredentials
+}
+
+// Unwrap returns the underlying error
+func (e *IncompleteCredentialsError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedSchemeError is returned when loading a source using a url scheme other than file, http, or https
+type UnsupportedSchemeError struct {
+	Scheme string
+}
+
+func (e *UnsupportedSchemeError) Error() string {
+	return fmt.Sprintf("url scheme '%s' not supported", e.Scheme)
+}
+
+// Is returns true if the target is ErrUnsupportedScheme
+func (e *UnsupportedSchemeError) Is(target error) bool {
+	return target == ErrUnsupportedScheme
+}
+
+// SourceProfileCycleError is returned when following the source_profile attributes of a profile leads back to a
+// profile already seen.  Chain is the list of profile names followed, ending with the repeated profile.  A profile
+// referencing itself as the source_profile is allowed (the AWS SDK and CLI use its credentials), and is not a cycle.
+type SourceProfileCycleError struct {
+	Chain []string
+}
+
+func (e *SourceProfileCycleError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrSourceProfileCycle.Error(), strings.Join(e.Chain, " -> "))
+}
+
+// Is returns true if the target is ErrSourceProfileCycle
+func (e *SourceProfileCycleError) Is(target error) bool {
+	return target == ErrSourceProfileCycle
+}
+
+// InheritCycleError is returned when following the x_inherit attributes of a profile leads back to a profile already
+// seen.  Chain is the list of profile names followed, ending with the repeated profile.
+type InheritCycleError struct {
+	Chain []string
+}
+
+func (e *InheritCycleError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInheritCycle.Error(), strings.Join(e.Chain, " -> "))
+}
+
+// Is returns true if the target is ErrInheritCycle
+func (e *InheritCycleError) Is(target error) bool {
+	return target == ErrInheritCycle
+}
+
+// RoleProfileError is returned when looking up stored credentials for a profile with a role_arn attribute.  Using the
+// credentials of the profile's source_profile instead would act as the source principal, not the role.
+type RoleProfileError struct {
+	Profile string
+	RoleArn string
+}
+
+func (e *RoleProfileError) Error() string {
+	return fmt.Sprintf("profile '%s' assumes role %s, credentials must be obtained from AWS STS", e.Profile, e.RoleArn)
+}
+
+// Is returns true if the target is ErrRoleProfile
+func (e *RoleProfileError) Is(target error) bool {
+	return target == ErrRoleProfile
+}
+
+// checkSourceProfiles follows the source_profile attributes starting at the profile, returning a SourceProfileCycleError
+// if a profile is found more than once.  The next function returns the source_profile of the named profile, and false
+// if the profile was not found, which ends the chain (the profile may only exist in the credentials file).  A profile
+// using itself as the source_profile also ends the chain.
+func checkSourceProfiles(profile, src string, next func(name string) (string, bool)) error {
+	chain := []string{profile}
+	seen := map[string]bool{profile: true}
+
+	for len(src) > 0 && src != chain[len(chain)-1] {
+		chain = append(chain, src)
+		if seen[src] {
+			return &SourceProfileCycleError{Chain: chain}
+		}
+		seen[src] = true
+
+		s, ok := next(src)
+		if !ok {
+			break
+		}
+		src = s
+	}
+	return nil
+}
+
+// ParseError is returned when the source data is not valid ini data.  Line is the 1-based line number of the
+// problem in the source, or 0 if it could not be determined.  The go-ini error is available using errors.Unwrap().
+type ParseError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	loc := e.Path
+	if len(loc) < 1 {
+		loc = "<data>"
+	}
+
+	if e.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", loc, e.Line)
+	}
+	// go-ini errors may include the line terminator of the source line
+	return fmt.Sprintf("%s: %s", loc, strings.TrimSpace(e.Err.Error()))
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError creates a ParseError for the go-ini error.  Since go-ini doesn't report line numbers, the line is
+// found by searching the raw data for the text of the line in the error message.
+func newParseError(path string, raw []byte, err error) *ParseError {
+	var text string
+	if e, ok := err.(ini.ErrDelimiterNotFound); ok {
+		text = e.Line
+	} else if i := strings.LastIndex(err.Error(), ": "); i > -1 {
+		text = err.Error()[i+2:]
+	}
+	text = strings.TrimSpace(text)
+
+	pe := &ParseError{Path: path, Err: err}
+	if len(text) < 1 {
+		return pe
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(raw))
+	for n := 1; s.Scan(); n++ {
+		if strings.TrimSpace(s.Text()) == text {
+			pe.Line = n
+			break
+		}
+	}
+	return pe
+}
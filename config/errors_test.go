@@ -0,0 +1,191 @@
+package config
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestErrors_ProfileNotFound(t *testing.T) {
+	cp, err := NewIniConfigProvider(ConfFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer cp.Close()
+
+	cr, err := NewIniCredentialProvider(credFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer cr.Close()
+
+	r, err := NewAwsConfigResolver(ConfFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	errs := make([]error, 0)
+	_, err = cp.Config("missing")
+	errs = append(errs, err)
+	_, err = cr.Credentials("missing")
+	errs = append(errs, err)
+	_, err = r.Resolve("missing")
+	errs = append(errs, err)
+	_, err = NewMemoryConfigProvider().Config("missing")
+	errs = append(errs, err)
+	_, err = NewMemoryCredentialProvider().Credentials("missing")
+	errs = append(errs, err)
+
+	for i, err := range errs {
+		if !errors.Is(err, ErrProfileNotFound) {
+			t.Errorf("error %d is not ErrProfileNotFound: %v", i, err)
+		}
+
+		var e *ProfileNotFoundError
+		if !errors.As(err, &e) || e.Profile != "missing" {
+			t.Errorf("error %d is not a ProfileNotFoundError: %v", i, err)
+		}
+	}
+}
+
+func TestErrors_IncompleteCredentials(t *testing.T) {
+	cr, err := NewIniCredentialProvider(credFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer cr.Close()
+
+	t.Run("ini", func(t *testing.T) {
+		_, err := cr.Credentials("no-secret")
+		if !errors.Is(err, ErrIncompleteCredentials) {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		var e *IncompleteCredentialsError
+		if !errors.As(err, &e) || e.Profile != "no-secret" {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("env", func(t *testing.T) {
+		env := MapEnvironment{"AWS_ACCESS_KEY_ID": "mykey"}
+		_, err := NewEnvCredentialProvider(WithEnvironment(env)).Credentials()
+		if !errors.Is(err, ErrIncompleteCredentials) || !errors.Is(err, credentials.ErrSecretAccessKeyNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		p := NewMemoryCredentialProvider().WithCredentials("x", credentials.Value{AccessKeyID: "mykey"})
+		if _, err := p.Credentials("x"); !errors.Is(err, ErrIncompleteCredentials) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestErrors_UnsupportedScheme(t *testing.T) {
+	u := url.URL{Scheme: "ftp", Host: "localhost", Path: ConfFileName}
+	_, err := NewIniConfigProvider(&u)
+	if !errors.Is(err, ErrUnsupportedScheme) {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+
+	var e *UnsupportedSchemeError
+	if !errors.As(err, &e) || e.Scheme != "ftp" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestErrors_SourceProfileCycle(t *testing.T) {
+	cfg := []byte(`[default]
+region = us-east-1
+
+[profile a]
+role_arn = arn:aws:iam::123456789012:role/A
+source_profile = b
+
+[profile b]
+role_arn = arn:aws:iam::123456789012:role/B
+source_profile = c
+
+[profile c]
+source_profile = a
+
+[profile self]
+source_profile = self
+`)
+
+	r, err := NewAwsConfigResolver(cfg)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := r.Resolve("a")
+		if !errors.Is(err, ErrSourceProfileCycle) {
+			t.Errorf("unexpected error: %v", err)
+			return
+		}
+
+		var e *SourceProfileCycleError
+		if !errors.As(err, &e) || !reflect.DeepEqual(e.Chain, []string{"a", "b", "c", "a"}) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("self reference", func(t *testing.T) {
+		if _, err := r.Resolve("self"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		r, _ := NewAwsConfigResolver(cfg)
+		if _, err := r.WithLookupSourceProfile(false).Resolve("a"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("lint", func(t *testing.T) {
+		p, _ := NewIniConfigProvider(cfg)
+
+		n := 0
+		for _, d := range p.Lint() {
+			if d.Attribute == "source_profile" && d.Severity == SeverityError {
+				n++
+			}
+		}
+
+		if n != 3 {
+			t.Errorf("expected 3 cycle diagnostics, found %d", n)
+		}
+	})
+}
+
+func TestErrors_ParseError(t *testing.T) {
+	cfg := []byte("[default]\nregion = us-east-1\n\nnot a key value pair\n")
+
+	_, err := NewIniConfigProvider(cfg)
+
+	var e *ParseError
+	if !errors.As(err, &e) {
+		t.Errorf("unexpected error: %v", err)
+		return
+	}
+
+	if e.Line != 4 || errors.Unwrap(err) == nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if e.Error() != "<data>:4: key-value delimiter not found: not a key value pair" {
+		t.Errorf("unexpected message: %q", e.Error())
+	}
+}
@@ -0,0 +1,311 @@
+package config
+
+import (
+	"fmt"
+	"github.com/go-ini/ini"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IncludeSection is the name of the section listing the files included in a config file.  Each attribute value in the
+// section is a file path or glob pattern (relative paths are relative to the including file, and a leading ~ is the
+// user's home directory), for example:
+//
+//	[include]
+//	org   = /etc/aws/org-profiles
+//	local = ~/.aws/config.d/*
+//
+// Included files are loaded in the order listed (glob matches in lexical order), and have a lower precedence than the
+// including file, so profile attributes in the including file override the same attributes in included files.
+// SaveTo() and WriteTo() only write the including file, with any changed attributes of included profiles written to
+// the including file as overrides.  Attributes removed from included profiles are not written.  Includes are not
+// supported in credentials files, where profile sections are named without the "profile " prefix, so an [include]
+// section is the profile named include, or in sources loaded from an http(s) url.
+const IncludeSection = "include"
+
+// sourceData is the plaintext data of a single source, and the ini data parsed from it
+type sourceData struct {
+	name string
+	raw  []byte
+	file *ini.File
+}
+
+// provenance records the sources defining each section and attribute of the loaded data, and the line numbers of the
+// sections and attributes in each source.  The last source is the one the data is written to.
+type provenance struct {
+	sections map[string][]string
+	keys     map[string]map[string]string
+	lines    map[string]*lineIndex
+	last     string
+}
+
+func newProvenance(srcs []*sourceData) *provenance {
+	p := &provenance{sections: make(map[string][]string), keys: make(map[string]map[string]string),
+		lines: make(map[string]*lineIndex), last: srcs[len(srcs)-1].name}
+
+	for _, src := range srcs {
+		p.lines[src.name] = newLineIndex(src.raw)
+
+		for _, s := range src.file.Sections() {
+			n := s.Name()
+			if n == ini.DefaultSection && len(s.Keys()) < 1 {
+				continue
+			}
+
+			p.sections[n] = append(p.sections[n], src.name)
+			if _, ok := p.keys[n]; !ok {
+				p.keys[n] = make(map[string]string)
+			}
+
+			for _, k := range s.KeyStrings() {
+				p.keys[n][k] = src.name
+			}
+		}
+	}
+
+	return p
+}
+
+// ProfileSources returns the names of the sources which define the profile, in order of precedence (lowest first).
+// The names are file paths, or urls, and are empty for []byte and io.Reader sources.  If the profile section is not
+// found, the section with the "profile " prefix is used.
+func (f *awsConfigFile) ProfileSources(profile string) []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, n := range []string{profile, "profile " + profile} {
+		if s, ok := f.prov.sections[n]; ok {
+			return append([]string{}, s...)
+		}
+	}
+	return []string{}
+}
+
+// AttributeSource returns the name of the source providing the value of the profile attribute, and a boolean
+// indicating if the attribute was found.  Attributes added after the data was loaded are not found.
+func (f *awsConfigFile) AttributeSource(profile, attr string) (string, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, n := range []string{profile, "profile " + profile} {
+		if s, ok := f.prov.keys[n][attr]; ok {
+			return s, ok
+		}
+	}
+	return "", false
+}
+
+// sectionSource returns the name of the highest precedence source defining the section, or the last source for
+// sections added after the data was loaded.  The caller is expected to hold the mutex.
+func (f *awsConfigFile) sectionSource(section string) string {
+	if s := f.prov.sections[section]; len(s) > 0 {
+		return s[len(s)-1]
+	}
+	return f.prov.last
+}
+
+// sectionLine returns the name of the highest precedence source defining the section, and the line number of the
+// section in the source.  The caller is expected to hold the mutex.
+func (f *awsConfigFile) sectionLine(section string) (string, int) {
+	src := f.sectionSource(section)
+	return src, f.prov.lines[src].section(section)
+}
+
+// keyLine returns the name of the source providing the value of the attribute, and the line number of the attribute in
+// the source.  For attributes added after the data was loaded, the source and line number of the section are returned.
+// The caller is expected to hold the mutex.
+func (f *awsConfigFile) keyLine(section, key string) (string, int) {
+	if src, ok := f.prov.keys[section][key]; ok {
+		return src, f.prov.lines[src].key(section, key)
+	}
+	return f.sectionLine(section)
+}
+
+// sources reads the source, and any included files.  A []string source is a list of sources (file paths, glob
+// patterns, or urls), where later sources have a higher precedence.  The returned sources are in order of precedence
+// (lowest first), and the last source is the one the data is written to by SaveTo() and WriteTo().
+func (f *awsConfigFile) sources(source interface{}, def func(f *awsConfigFile), o *options) ([]*sourceData, error) {
+	seen := make(map[string]bool)
+
+	list, ok := source.([]string)
+	if !ok {
+		raw, err := f.read(source, def)
+		if err != nil {
+			return nil, err
+		}
+
+		// data fetched from a url is saved to a temporary file, so it's known by the url
+		name := f.Path
+		if f.isTemp {
+			name = sourceUrl(source)
+		}
+		return f.parse(name, raw, o, seen)
+	}
+
+	srcs := make([]*sourceData, 0)
+	for _, l := range list {
+		names, err := expandSource(l, "")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, n := range names {
+			sf := &awsConfigFile{log: f.log}
+			raw, err := sf.read(n, nil)
+			_ = sf.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			// data fetched from a url is not saved, so it's known by the url
+			if sf.isTemp {
+				sf.Path = ""
+			}
+
+			x, err := sf.parse(n, raw, o, seen)
+			if err != nil {
+				return nil, err
+			}
+			srcs = append(srcs, x...)
+			f.Path, f.enc = sf.Path, sf.enc
+		}
+	}
+
+	if len(srcs) < 1 {
+		f.logger().Debug("no sources found, using empty default profile", "sources", strings.Join(list, ","))
+		return f.parse("", []byte("[default]"), o, seen)
+	}
+	return srcs, nil
+}
+
+// parse decrypts and parses the raw data of the named source, and returns the data of the files it includes followed
+// by the data for the source.  Files already in the seen set are not included again.
+func (f *awsConfigFile) parse(name string, raw []byte, o *options, seen map[string]bool) ([]*sourceData, error) {
+	var err error
+	if isEncrypted(raw) {
+		if raw, err = f.decrypt(raw, o); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := ini.Load(raw)
+	if err != nil {
+		return nil, newParseError(name, raw, err)
+	}
+
+	if len(name) > 0 {
+		if abs, err := filepath.Abs(name); err == nil {
+			seen[abs] = true
+		}
+	}
+
+	srcs := make([]*sourceData, 0)
+	if s, err := file.GetSection(IncludeSection); err == nil && o.includes {
+		if isHttpSource(name) && len(s.Keys()) > 0 {
+			return nil, fmt.Errorf("%s: includes are not supported in url sources", name)
+		}
+
+		for _, k := range s.Keys() {
+			x, err := f.include(name, k.Value(), o, seen)
+			if err != nil {
+				return nil, err
+			}
+			srcs = append(srcs, x...)
+		}
+	}
+
+	return append(srcs, &sourceData{name: name, raw: raw, file: file}), nil
+}
+
+// include returns the source data for the files matching the pattern, relative to the directory of the including file
+func (f *awsConfigFile) include(from, pattern string, o *options, seen map[string]bool) ([]*sourceData, error) {
+	dir := "."
+	if len(from) > 0 {
+		dir = filepath.Dir(from)
+	}
+
+	names, err := expandSource(pattern, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	srcs := make([]*sourceData, 0)
+	for _, n := range names {
+		if abs, err := filepath.Abs(n); err == nil && seen[abs] {
+			f.logger().Debug("skipping file already included", "path", n, "from", from)
+			continue
+		}
+
+		raw, err := ioutil.ReadFile(n)
+		if err != nil {
+			return nil, err
+		}
+		f.logger().Debug("including file", "path", n, "from", from)
+
+		// included files are decrypted with the same options, but are never written, so don't keep their encryption
+		x, err := (&awsConfigFile{Path: n, log: f.log}).parse(n, raw, o, seen)
+		if err != nil {
+			return nil, err
+		}
+		srcs = append(srcs, x...)
+	}
+
+	return srcs, nil
+}
+
+// expandSource returns the file names for the source, expanding a leading ~ to the user's home directory, resolving
+// relative paths to the directory (if not empty), and expanding glob patterns.  Urls are returned unchanged.
+func expandSource(source, dir string) ([]string, error) {
+	if strings.Contains(source, "://") {
+		return []string{source}, nil
+	}
+
+	p := source
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, `~\`) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		p = filepath.Join(home, p[1:])
+	}
+
+	if len(dir) > 0 && !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+
+	if !strings.ContainsAny(p, "*?[") {
+		return []string{p}, nil
+	}
+	return filepath.Glob(p)
+}
+
+// isHttpSource returns true if the source name is an http(s) url
+func isHttpSource(name string) bool {
+	n := strings.ToLower(name)
+	return strings.HasPrefix(n, "http://") || strings.HasPrefix(n, "https://")
+}
+
+// sourceUrl returns the url string of a source fetched from a url
+func sourceUrl(source interface{}) string {
+	if u, ok := source.(*url.URL); ok {
+		return u.String()
+	}
+	return fmt.Sprint(source)
+}
+
+// withIncludes is the Option enabling the IncludeSection handling of config files.  Credentials file sections are
+// named without the "profile " prefix, so a section named include is a profile in the credentials file.
+func withIncludes() Option {
+	return func(o *options) {
+		o.includes = true
+	}
+}
+
+// isProfileSection returns true if the section holds profile data, and isn't the go-ini DEFAULT section or the
+// include section of a config file
+func (f *awsConfigFile) isProfileSection(name string) bool {
+	return name != ini.DefaultSection && !(f.includes && name == IncludeSection)
+}
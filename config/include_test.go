@@ -0,0 +1,222 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func includeDir(t *testing.T) string {
+	d, err := ioutil.TempDir("", "aws-config-include-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Mkdir(filepath.Join(d, "config.d"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"org":              "[default]\nregion = us-east-1\n\n[profile shared]\nregion = us-east-1\noutput = json\n",
+		"config.d/a.conf":  "[profile a]\nregion = eu-west-1\n\n[profile shared]\noutput = text\n",
+		"config.d/b.conf":  "[include]\nloop = ../config\n\n[profile b]\nregion = eu-west-2\n",
+		"config.d/ignored": "[profile ignored]\n",
+		"config":           "# my profiles\n[include]\norg = org\nlocal = config.d/*.conf\n\n[profile shared]\nregion = us-west-2\n",
+	}
+
+	for k, v := range files {
+		if err := ioutil.WriteFile(filepath.Join(d, k), []byte(v), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return d
+}
+
+func TestInclude(t *testing.T) {
+	d := includeDir(t)
+	defer os.RemoveAll(d)
+
+	p, err := NewIniConfigProvider(filepath.Join(d, "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("profiles", func(t *testing.T) {
+		if l := strings.Join(p.ListProfiles(false), ","); l != "a,b,default,shared" {
+			t.Errorf("unexpected profiles: %s", l)
+		}
+	})
+
+	t.Run("precedence", func(t *testing.T) {
+		c, err := p.Config("shared")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Region != "us-west-2" || c.Get("output") != "text" {
+			t.Errorf("unexpected config: %+v", c)
+		}
+	})
+
+	t.Run("provenance", func(t *testing.T) {
+		s := p.ProfileSources("shared")
+		if len(s) != 3 || s[0] != filepath.Join(d, "org") || s[2] != filepath.Join(d, "config") {
+			t.Errorf("unexpected sources: %v", s)
+		}
+
+		if s, ok := p.AttributeSource("shared", "output"); !ok || s != filepath.Join(d, "config.d", "a.conf") {
+			t.Errorf("unexpected source: %s", s)
+		}
+
+		if _, ok := p.AttributeSource("shared", "nope"); ok {
+			t.Error("found unexpected attribute")
+		}
+
+		for _, e := range p.QueryProfiles(nil) {
+			if e.Name == "b" && e.Source != filepath.Join(d, "config.d", "b.conf") {
+				t.Errorf("unexpected entry: %+v", e)
+			}
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		if err := p.SetAttribute("a", "output", "table"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.SetAttribute("shared", "output", "text"); err != nil {
+			t.Fatal(err)
+		}
+
+		b := new(bytes.Buffer)
+		if _, err := p.WriteTo(b); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "# my profiles\n[include]\norg = org\nlocal = config.d/*.conf\n\n[profile shared]\nregion = us-west-2\n\n[profile a]\noutput = table\n"
+		if b.String() != expected {
+			t.Errorf("unexpected output:\n%s", b.String())
+		}
+	})
+}
+
+func TestIncludeList(t *testing.T) {
+	d := includeDir(t)
+	defer os.RemoveAll(d)
+
+	p, err := NewIniConfigProvider([]string{filepath.Join(d, "config.d", "*.conf"), filepath.Join(d, "org")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Path != filepath.Join(d, "org") {
+		t.Errorf("unexpected path: %s", p.Path)
+	}
+
+	c, err := p.Config("shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Region != "us-east-1" || c.Get("output") != "json" {
+		t.Errorf("unexpected config: %+v", c)
+	}
+
+	t.Run("no matches", func(t *testing.T) {
+		p, err := NewIniConfigProvider([]string{filepath.Join(d, "nope", "*")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if l := p.ListProfiles(false); len(l) != 1 || l[0] != DefaultProfileName {
+			t.Errorf("unexpected profiles: %v", l)
+		}
+	})
+
+	t.Run("missing include", func(t *testing.T) {
+		if _, err := NewIniConfigProvider([]byte("[include]\nx = " + filepath.Join(d, "nope"))); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("bad include", func(t *testing.T) {
+		bad := filepath.Join(d, "bad")
+		if err := ioutil.WriteFile(bad, []byte("[profile bad\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := NewIniConfigProvider([]byte("[include]\nx = " + bad))
+		e := new(ParseError)
+		if !errors.As(err, &e) || e.Path != bad {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestIncludeCredentials(t *testing.T) {
+	p, err := NewIniCredentialProvider([]byte("[include]\naws_access_key_id = AKIAINCLUDE\naws_secret_access_key = s3cr3t\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l := p.ProfileStrings(); len(l) != 1 || l[0] != IncludeSection {
+		t.Errorf("unexpected profiles: %v", l)
+	}
+
+	v, err := p.Credentials(IncludeSection)
+	if err != nil || v.AccessKeyID != "AKIAINCLUDE" {
+		t.Errorf("unexpected credentials: %+v, %v", v, err)
+	}
+
+	if d := p.Lint(); len(d) > 0 {
+		t.Errorf("unexpected diagnostics: %v", d)
+	}
+}
+
+func TestIncludeUrl(t *testing.T) {
+	files := map[string]string{
+		"/config":   "[include]\norg = org\n\n[profile a]\nregion = us-west-2\n",
+		"/org":      "[profile org]\nregion = us-east-1\n",
+		"/standard": "[profile a]\nregion = us-west-2\n",
+	}
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v, ok := files[r.URL.Path]; ok {
+			w.Write([]byte(v))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer svr.Close()
+
+	t.Run("no includes", func(t *testing.T) {
+		p, err := NewIniConfigProvider(svr.URL + "/standard")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+
+		if s := p.ProfileSources("a"); len(s) != 1 || s[0] != svr.URL+"/standard" {
+			t.Errorf("unexpected sources: %v", s)
+		}
+	})
+
+	t.Run("url", func(t *testing.T) {
+		if _, err := NewIniConfigProvider(svr.URL + "/config"); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		if _, err := NewIniConfigProvider([]string{svr.URL + "/org", svr.URL + "/config"}); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
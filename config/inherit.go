@@ -0,0 +1,103 @@
+package config
+
+import "regexp"
+
+// InheritAttribute is the profile attribute naming the profile to inherit attributes from.  Inheritance only copies
+// attributes (unlike source_profile, which also selects the credentials), the attributes of the profile override the
+// attributes of the profile it inherits from (an empty value clears the inherited value), and the inherited profile
+// may itself inherit from another profile.
+// Together with variable interpolation, a template profile is able to define the attributes common to many profiles:
+//
+//	[profile account-template]
+//	role_arn       = arn:aws:iam::${account_id}:role/Admin
+//	source_profile = default
+//
+//	[profile prod]
+//	x_inherit  = account-template
+//	account_id = 123456789012
+const InheritAttribute = "x_inherit"
+
+// variablePattern matches the ${name} variable references in attribute values
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z0-9_.-]+)\}`)
+
+// inheritChain returns the configuration for the profile, preceded by the configurations of the profiles it inherits
+// from (the most distant first).  An InheritCycleError is returned if a profile is found more than once.
+func inheritChain(cp AwsConfigProvider, name string) ([]*AwsConfig, error) {
+	c, err := cp.Config(name)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []*AwsConfig{c}
+	names := []string{name}
+	seen := map[string]bool{name: true}
+
+	for p := c.Get(InheritAttribute); len(p) > 0; p = chain[0].Get(InheritAttribute) {
+		names = append(names, p)
+		if seen[p] {
+			return nil, &InheritCycleError{Chain: names}
+		}
+		seen[p] = true
+
+		x, err := cp.Config(p)
+		if err != nil {
+			return nil, err
+		}
+		chain = append([]*AwsConfig{x}, chain...)
+	}
+
+	return chain, nil
+}
+
+// mergeInherited combines the configurations of the inheritance chain returned by inheritChain().  Unlike Merge(), an
+// attribute set in a profile overrides the inherited value even if it is empty or "0", so a profile is able to clear
+// the attributes it inherits.
+func mergeInherited(chain []*AwsConfig) *AwsConfig {
+	c := &AwsConfig{rawAttributes: make(map[string]string), sources: make(map[string]string)}
+
+	for _, x := range chain {
+		for k, v := range x.rawAttributes {
+			c.rawAttributes[k] = v
+			c.sources[k] = x.Source(k)
+		}
+		c.Profile = x.Profile
+	}
+
+	c.setFields()
+	return c
+}
+
+// interpolate replaces the ${name} variable references in the attribute values with the value of the named attribute.
+// The profile variable is the name of the profile, unless a profile attribute is set.  References to undefined
+// variables are left unchanged, and values are only expanded once, so variable values aren't interpolated.
+func interpolate(c *AwsConfig) *AwsConfig {
+	vars := c.Attributes()
+	if _, ok := vars["profile"]; !ok {
+		vars["profile"] = c.Profile
+	}
+
+	attrs := make(map[string]string, len(vars))
+	for k, v := range c.rawAttributes {
+		attrs[k] = expandVariables(v, vars)
+	}
+
+	n := &AwsConfig{Profile: c.Profile, rawAttributes: attrs, sources: c.sources}
+	n.setFields()
+	return n
+}
+
+// expandVariables replaces the ${name} variable references in the value with the values of the variables, leaving
+// references to undefined variables unchanged
+func expandVariables(v string, vars map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(v, func(m string) string {
+		if x, ok := vars[m[2:len(m)-1]]; ok {
+			return x
+		}
+		return m
+	})
+}
+
+// hasVariables returns true if the value contains a ${name} variable reference
+func hasVariables(v string) bool {
+	return variablePattern.MatchString(v)
+}
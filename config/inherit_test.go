@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+var inheritConfig = []byte(`[default]
+region = us-east-1
+
+[profile base]
+role_arn          = arn:aws:iam::${account_id}:role/Admin
+source_profile    = default
+role_session_name = ${profile}-session
+external_id       = ${HOME}
+
+[profile account-template]
+x_inherit = base
+region    = us-west-2
+
+[profile prod]
+x_inherit  = account-template
+account_id = 123456789012
+
+[profile dev]
+x_inherit  = account-template
+account_id = 210987654321
+region     = eu-west-1
+
+[profile loop-a]
+x_inherit = loop-b
+
+[profile loop-b]
+x_inherit = loop-a
+
+[profile orphan]
+x_inherit = nope
+
+[profile mfa-template]
+x_inherit        = base
+mfa_serial       = arn:aws:iam::123456789012:mfa/me
+duration_seconds = 3600
+
+[profile no-mfa]
+x_inherit        = mfa-template
+account_id       = 123456789012
+mfa_serial       =
+duration_seconds = 0
+
+[profile source-template]
+source_profile = default
+
+[profile generated]
+x_inherit = source-template
+role_arn  = arn:aws:iam::123456789012:role/Admin
+
+[profile no-role]
+x_inherit = base
+role_arn  =
+
+[profile no-source]
+x_inherit      = base
+source_profile =
+`)
+
+func TestAwsConfigResolver_Inherit(t *testing.T) {
+	r, err := NewAwsConfigResolver(inheritConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("template", func(t *testing.T) {
+		c, err := r.Resolve("prod")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.RoleArn != "arn:aws:iam::123456789012:role/Admin" || c.Region != "us-west-2" ||
+			c.SourceProfile != DefaultProfileName || c.RoleSessionName != "prod-session" {
+			t.Errorf("data mismatch: %+v", c)
+		}
+
+		if c.Source("role_arn") != "base" || c.Source("region") != "account-template" || c.Source("account_id") != "prod" {
+			t.Error("source mismatch")
+		}
+
+		// undefined variables are not replaced
+		if c.ExternalId != "${HOME}" {
+			t.Errorf("unexpected external_id: %s", c.ExternalId)
+		}
+	})
+
+	t.Run("override", func(t *testing.T) {
+		c, err := r.Resolve("dev")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.RoleArn != "arn:aws:iam::210987654321:role/Admin" || c.Region != "eu-west-1" {
+			t.Errorf("data mismatch: %+v", c)
+		}
+	})
+
+	t.Run("clear inherited", func(t *testing.T) {
+		c, err := r.Resolve("no-mfa")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(c.MfaSerial) > 0 || c.DurationSeconds != 0 || c.RoleArn != "arn:aws:iam::123456789012:role/Admin" {
+			t.Errorf("data mismatch: %+v", c)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := r.Resolve("loop-a")
+		e := new(InheritCycleError)
+		if !errors.Is(err, ErrInheritCycle) || !errors.As(err, &e) || len(e.Chain) != 3 {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := r.Resolve("orphan"); !errors.Is(err, ErrProfileNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestIniConfigProvider_LintInherit(t *testing.T) {
+	p, err := NewIniConfigProvider(inheritConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := p.Lint()
+	for _, x := range d {
+		switch x.Profile {
+		case "loop-a", "loop-b", "orphan":
+			if x.Severity != SeverityError || x.Attribute != InheritAttribute {
+				t.Errorf("unexpected diagnostic: %v", x)
+			}
+		case "no-source":
+			if x.Severity != SeverityError || x.Attribute != "role_arn" {
+				t.Errorf("unexpected diagnostic: %v", x)
+			}
+		default:
+			t.Errorf("unexpected diagnostic: %v", x)
+		}
+	}
+
+	if len(d) != 4 {
+		t.Errorf("unexpected diagnostics: %v", d)
+	}
+}
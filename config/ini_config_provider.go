@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws/defaults"
 	"github.com/go-ini/ini"
-	"os"
-	"sort"
 	"strings"
 )
 
@@ -19,33 +17,47 @@ type IniConfigProvider struct {
 
 // NewIniConfigProvider initializes a default IniConfigProvider using the specified source.  Valid sources
 // include, a string representing a file path or url (file and http(s) urls supported), a Golang *url.URL, an []byte,
-// a *os.File, or an io.Reader
-func NewIniConfigProvider(source interface{}) (*IniConfigProvider, error) {
+// a *os.File, an io.Reader, or a []string list of file paths, glob patterns, or urls, where attributes in later sources
+// override the same attributes in earlier sources.  Files are also able to include other files using an [include]
+// section (see IncludeSection).  If the source is nil, the file named by the AWS_CONFIG_FILE environment variable, or
+// the default config file location is used.
+func NewIniConfigProvider(source interface{}, opts ...Option) (*IniConfigProvider, error) {
+	o := newOptions(opts)
+
 	cf, err := load(source, func(f *awsConfigFile) {
-		s := defaults.SharedConfigFilename()
-		if e, ok := os.LookupEnv(ConfigFileEnvVar); ok {
-			s = e
-		}
-		f.Path = s
+		f.Path = o.sharedFilename(ConfigFileEnvVar, "config", defaults.SharedConfigFilename)
 		f.isTemp = false
-	})
+	}, append([]Option{withIncludes()}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
+	cf.policy = o.policy()
 
 	return &IniConfigProvider{cf}, nil
 }
 
-// Config will return the configuration attributes for the specified profile.  If the profile is nil, the
-// configuration of the default profile will be returned.
+// WithProfilePolicy is a fluent method for setting the ProfilePolicy used to select the profile when a profile
+// name is not provided
+func (p *IniConfigProvider) WithProfilePolicy(policy *ProfilePolicy) *IniConfigProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.policy = policy
+	return p
+}
+
+// Config will return the configuration attributes for the specified profile.  If the profile is nil or empty, the
+// profile is selected using the provider's ProfilePolicy (the AWS_DEFAULT_PROFILE or AWS_PROFILE environment
+// variables, or the default profile, unless configured otherwise).
 func (p *IniConfigProvider) Config(profile ...string) (*AwsConfig, error) {
 	c := new(AwsConfig)
 
-	if profile == nil || len(profile) < 1 {
-		profile = []string{DefaultProfileName}
-	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	name := policyOrDefault(p.policy).Resolve(firstOrEmpty(profile))
 
-	s, err := p.Profile(profile[0])
+	s, err := p.configProfile(name)
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +67,7 @@ func (p *IniConfigProvider) Config(profile ...string) (*AwsConfig, error) {
 	}
 
 	c.rawAttributes = s.KeysHash()
-	c.Profile = profile[0]
+	c.Profile = name
 
 	return c, nil
 }
@@ -63,32 +75,104 @@ func (p *IniConfigProvider) Config(profile ...string) (*AwsConfig, error) {
 // Profile overrides the default Profile lookup logic to include a callback
 // to re-try the lookup with "profile " prepended to the name
 func (p *IniConfigProvider) Profile(profile string) (*ini.Section, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.configProfile(profile)
+}
+
+// configProfile is the Profile lookup for callers already holding the mutex
+func (p *IniConfigProvider) configProfile(profile string) (*ini.Section, error) {
 	return p.profile(profile, func(n string) string {
 		return fmt.Sprintf("profile %s", n)
 	})
 }
 
+// SetAttribute sets the value of the attribute in the profile, creating the profile section if it doesn't exist.
+// New profile sections are created using the "profile " prefix for all profiles except the default profile.
+// Updates are only made to the in-memory representation of the data, it is the caller's responsibility to persist
+// the information to storage, either via the SaveTo() or WriteTo() methods.
+func (p *IniConfigProvider) SetAttribute(profile, attr, value string) error {
+	if len(profile) < 1 || len(attr) < 1 {
+		return fmt.Errorf("profile and attribute names are required")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, err := p.configProfile(profile)
+	if err != nil {
+		n := profile
+		if n != DefaultProfileName {
+			n = fmt.Sprintf("profile %s", profile)
+		}
+
+		if s, err = p.NewSection(n); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.NewKey(attr, value)
+	return err
+}
+
+// UnsetAttribute removes the attribute from the profile.  Removing an attribute which is not set in the profile is
+// not an error, however the profile must exist.  Updates are only made to the in-memory representation of the data,
+// it is the caller's responsibility to persist the information to storage.
+func (p *IniConfigProvider) UnsetAttribute(profile, attr string) error {
+	if len(profile) < 1 {
+		return fmt.Errorf("profile name is required")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, err := p.configProfile(profile)
+	if err != nil {
+		return err
+	}
+
+	s.DeleteKey(attr)
+	return nil
+}
+
 // ListProfiles will return an array of profile names found in the config file.  If the roles arg is false,
 // then all profile sections found in the config file will be returned; otherwise only profile sections which
 // have the role_arn property will be returned.
 func (p *IniConfigProvider) ListProfiles(roles bool) []string {
-	profiles := make([]string, 0)
+	q := new(ProfileQuery)
+	if roles {
+		q.Attributes = map[string]string{"role_arn": ""}
+	}
+
+	entries := p.QueryProfiles(q)
+
+	profiles := make([]string, len(entries))
+	for i, e := range entries {
+		profiles[i] = e.Name
+	}
+	return profiles
+}
+
+// QueryProfiles will return the metadata for the profiles in the config file which match the provided query, sorted
+// by profile name.  A nil query will return all profiles.
+func (p *IniConfigProvider) QueryProfiles(q *ProfileQuery) []ProfileEntry {
+	entries := make([]ProfileEntry, 0)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 
 	for _, s := range p.Sections() {
-		if s.Name() == ini.DefaultSection {
+		if !p.isProfileSection(s.Name()) {
 			continue
 		}
 
 		n := strings.TrimPrefix(s.Name(), "profile ")
-		if roles {
-			if s.HasKey("role_arn") {
-				profiles = append(profiles, n)
-			}
-		} else {
-			profiles = append(profiles, n)
+		attrs := s.KeysHash()
+		if q.Matches(n, attrs) {
+			entries = append(entries, ProfileEntry{Name: n, Section: s.Name(), Kind: profileKind(attrs), Source: p.sectionSource(s.Name())})
 		}
 	}
 
-	sort.Strings(profiles)
-	return profiles
+	sortProfileEntries(entries)
+	return entries
 }
@@ -174,3 +174,139 @@ func TestIniConfigProvider_ListProfiles(t *testing.T) {
 		}
 	})
 }
+
+func TestIniConfigProvider_QueryProfiles(t *testing.T) {
+	f, err := NewIniConfigProvider(ConfFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer f.Close()
+
+	t.Run("nil query", func(t *testing.T) {
+		e := f.QueryProfiles(nil)
+		if len(e) != len(f.ListProfiles(false)) {
+			t.Error("did not find expected number of profiles")
+			return
+		}
+
+		for i := 1; i < len(e); i++ {
+			if e[i-1].Name > e[i].Name {
+				t.Error("profiles not sorted")
+			}
+		}
+	})
+
+	t.Run("role kind", func(t *testing.T) {
+		e := f.QueryProfiles(&ProfileQuery{Kind: ProfileKindRole})
+		if len(e) != 1 {
+			t.Error("did not find expected number of role profiles")
+			return
+		}
+
+		if e[0].Name != "mfa" || e[0].Section != "profile mfa" || e[0].Source != ConfFileName {
+			t.Errorf("data mismatch: %+v", e[0])
+		}
+	})
+
+	t.Run("region", func(t *testing.T) {
+		e := f.QueryProfiles(&ProfileQuery{Attributes: map[string]string{"region": "us-*"}})
+		if len(e) != 2 {
+			t.Error("did not find expected number of profiles")
+		}
+	})
+
+	t.Run("custom attribute", func(t *testing.T) {
+		e := f.QueryProfiles(&ProfileQuery{Name: "o*", Attributes: map[string]string{"custom_attribute": ""}})
+		if len(e) != 1 || e[0].Name != "other" {
+			t.Error("did not find expected profile")
+		}
+	})
+}
+
+func TestIniConfigProvider_SetAttribute(t *testing.T) {
+	f, err := NewIniConfigProvider(ConfFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer f.Close()
+
+	t.Run("existing profile", func(t *testing.T) {
+		if err := f.SetAttribute("other", "region", "eu-central-1"); err != nil {
+			t.Error(err)
+			return
+		}
+
+		c, err := f.Config("other")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Region != "eu-central-1" {
+			t.Error("attribute not updated")
+		}
+	})
+
+	t.Run("new profile", func(t *testing.T) {
+		if err := f.SetAttribute("brand-new", "custom", "value"); err != nil {
+			t.Error(err)
+			return
+		}
+
+		s, err := f.Profile("brand-new")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if s.Name() != "profile brand-new" || s.Key("custom").String() != "value" {
+			t.Error("data mismatch")
+		}
+	})
+
+	t.Run("empty profile name", func(t *testing.T) {
+		if err := f.SetAttribute("", "region", "us-east-1"); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestIniConfigProvider_UnsetAttribute(t *testing.T) {
+	f, err := NewIniConfigProvider(ConfFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer f.Close()
+
+	t.Run("existing attribute", func(t *testing.T) {
+		if err := f.UnsetAttribute("other", "custom_attribute"); err != nil {
+			t.Error(err)
+			return
+		}
+
+		c, err := f.Config("other")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if len(c.Get("custom_attribute")) > 0 {
+			t.Error("attribute not removed")
+		}
+	})
+
+	t.Run("missing attribute", func(t *testing.T) {
+		if err := f.UnsetAttribute("other", "not-an-attribute"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("missing profile", func(t *testing.T) {
+		if err := f.UnsetAttribute("not-a-profile", "region"); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
@@ -5,7 +5,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/defaults"
 	"github.com/aws/aws-sdk-go/service/iam"
-	"os"
+	"time"
 )
 
 // CredentialsFileEnvVar is the credentials file environment variable name
@@ -14,30 +14,43 @@ const CredentialsFileEnvVar = "AWS_SHARED_CREDENTIALS_FILE"
 // IniCredentialProvider enables the lookup of AWS credentials from an ini-formatted data source
 type IniCredentialProvider struct {
 	*awsConfigFile
+	keyCreated bool
 }
 
 // NewIniCredentialProvider initializes a default IniCredentialProvider using the specified source.  Valid sources
 // include, a string representing a file path or url (file and http(s) urls supported), a Golang *url.URL, an []byte,
-// a *os.File, or an io.Reader
-func NewIniCredentialProvider(source interface{}) (*IniCredentialProvider, error) {
+// a *os.File, an io.Reader, or a []string list of file paths, glob patterns, or urls, where attributes in later sources
+// override the same attributes in earlier sources.  Unlike the config file, an [include] section is a profile named
+// include, not a list of included files.  If the source is nil, the file named by the AWS_SHARED_CREDENTIALS_FILE
+// environment variable, or the default credentials file location is used.
+func NewIniCredentialProvider(source interface{}, opts ...Option) (*IniCredentialProvider, error) {
+	o := newOptions(opts)
+
 	cf, err := load(source, func(f *awsConfigFile) {
-		s := defaults.SharedCredentialsFilename()
-		if e, ok := os.LookupEnv(CredentialsFileEnvVar); ok {
-			s = e
-		}
-		f.Path = s
+		f.Path = o.sharedFilename(CredentialsFileEnvVar, "credentials", defaults.SharedCredentialsFilename)
 		f.isTemp = false
-	})
+	}, opts...)
 	if err != nil {
 		return nil, err
 	}
+	cf.policy = o.policy()
+
+	return &IniCredentialProvider{awsConfigFile: cf, keyCreated: o.keyCreated}, nil
+}
+
+// WithProfilePolicy is a fluent method for setting the ProfilePolicy used to select the profile when a profile
+// name is not provided
+func (p *IniCredentialProvider) WithProfilePolicy(policy *ProfilePolicy) *IniCredentialProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	return &IniCredentialProvider{cf}, nil
+	p.policy = policy
+	return p
 }
 
 // Credentials will retrieve AWS credentials from the configured source location, for the provided profile.
-// If the profile argument is nil or empty, the value of the AWS_PROFILE environment variable will be used, and if
-// that isn't set, return credentials set in the "default" profile
+// If the profile argument is nil or empty, the profile is selected using the provider's ProfilePolicy (the
+// AWS_DEFAULT_PROFILE or AWS_PROFILE environment variables, or the default profile, unless configured otherwise)
 func (p *IniCredentialProvider) Credentials(profile ...string) (credentials.Value, error) {
 	v := credentials.Value{}
 
@@ -45,7 +58,10 @@ func (p *IniCredentialProvider) Credentials(profile ...string) (credentials.Valu
 		profile = []string{""}
 	}
 
-	pr, err := p.Profile(profile[0])
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pr, err := p.profile(profile[0], nil)
 	if err != nil {
 		return v, err
 	}
@@ -59,34 +75,88 @@ func (p *IniCredentialProvider) Credentials(profile ...string) (credentials.Valu
 	v.SecretAccessKey = c.SecretKey
 	v.SessionToken = c.SessionToken
 	if !v.HasKeys() {
-		return v, fmt.Errorf("incomplete credentials, missing access key and/or secret key")
+		return v, &IncompleteCredentialsError{Profile: pr.Name()}
 	}
 
 	return v, nil
 }
 
+// ExpiresAt returns the expiration time of the credentials for the profile, found in the x_security_token_expires
+// attribute of the profile.  If the attribute is not set, the zero time is returned.  Profile name resolution is the
+// same as the Credentials() method.
+func (p *IniCredentialProvider) ExpiresAt(profile ...string) (time.Time, error) {
+	if profile == nil || len(profile) < 1 {
+		profile = []string{""}
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pr, err := p.profile(profile[0], nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// use KeysHash() instead of Key(), which would add the attribute to the profile if it's not set
+	v := pr.KeysHash()[ExpirationAttribute]
+	if len(v) < 1 {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
 // UpdateCredentials updates the given profile with the provided credentials.  The creds can be an iam.AccessKey or
-// credentials.Value type (or pointers to either).  Updates are only made to the in-memory representation of the data,
-// it is the caller's responsibility to persist the information to storage, either via the SaveTo() or WriteTo() methods.
+// credentials.Value type (or pointers to either).  If the provider was created using the WithKeyCreatedTracking()
+// Option, the key creation time is recorded in the x_access_key_created attribute when the access key changes (using
+// the CreateDate of an iam.AccessKey, if set).  Updates are only made to the in-memory representation of the data, it
+// is the caller's responsibility to persist the information to storage, either via the SaveTo() or WriteTo() methods.
 func (p *IniCredentialProvider) UpdateCredentials(profile string, creds interface{}) error {
+	c, err := newAwsCredentials(creds)
+	if err != nil || c == nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, err := p.profile(profile, nil)
+	if err != nil {
+		return err
+	}
+
+	attrs := s.KeysHash()
+	oldKey := attrs["aws_access_key_id"]
+	if err := s.ReflectFrom(c); err != nil {
+		return err
+	}
+
+	// don't add an empty session token attribute to profiles which never had one
+	if _, ok := attrs["aws_session_token"]; !ok && len(c.SessionToken) < 1 {
+		s.DeleteKey("aws_session_token")
+	}
+
+	if p.keyCreated {
+		setKeyCreated(s, oldKey, c, creds)
+	}
+	return nil
+}
+
+// newAwsCredentials converts the supported credential types (iam.AccessKey or credentials.Value, or pointers to either)
+// to an awsCredentials object.  If creds is nil, or an inactive iam.AccessKey, nil is returned.
+func newAwsCredentials(creds interface{}) (*awsCredentials, error) {
 	c := new(awsCredentials)
 
 	switch t := creds.(type) {
 	case nil:
-		return nil
+		return nil, nil
 	case iam.AccessKey:
-		if *t.Status == iam.StatusTypeActive {
-			c.AccessKey = *t.AccessKeyId
-			c.SecretKey = *t.SecretAccessKey
-		} else {
-			return nil
-		}
+		return newAwsCredentials(&t)
 	case *iam.AccessKey:
 		if *t.Status == iam.StatusTypeActive {
 			c.AccessKey = *t.AccessKeyId
 			c.SecretKey = *t.SecretAccessKey
 		} else {
-			return nil
+			return nil, nil
 		}
 	case credentials.Value:
 		c.AccessKey = t.AccessKeyID
@@ -97,13 +167,8 @@ func (p *IniCredentialProvider) UpdateCredentials(profile string, creds interfac
 		c.SecretKey = t.SecretAccessKey
 		c.SessionToken = t.SessionToken
 	default:
-		return fmt.Errorf("unsupported credential type")
-	}
-
-	s, err := p.Profile(profile)
-	if err != nil {
-		return err
+		return nil, fmt.Errorf("unsupported credential type")
 	}
 
-	return s.ReflectFrom(c)
+	return c, nil
 }
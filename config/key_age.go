@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/go-ini/ini"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeyCreatedAttribute is the credentials file attribute holding the creation time of the access key, in RFC3339 format.
+// It is written by UpdateCredentials() when the access key of a profile changes, if enabled using the
+// WithKeyCreatedTracking() Option.
+const KeyCreatedAttribute = "x_access_key_created"
+
+// WithKeyCreatedTracking is an Option for enabling the recording of the access key creation time in the
+// x_access_key_created attribute when the credentials of a profile are updated, so the key age can be reported
+// for keys the AWS CLI doesn't know the age of.  It is disabled by default, so updating credentials only changes the
+// credential attributes.
+func WithKeyCreatedTracking(enabled bool) Option {
+	return func(o *options) {
+		o.keyCreated = enabled
+	}
+}
+
+// DefaultMaxKeyAge is the maximum access key age of the default KeyAgePolicy
+const DefaultMaxKeyAge = 90 * 24 * time.Hour
+
+// KeyAgePolicy defines the rules used to check the age of access keys in the credentials file
+type KeyAgePolicy struct {
+	maxAge         time.Duration
+	requireCreated bool
+	now            func() time.Time
+}
+
+// NewKeyAgePolicy creates a KeyAgePolicy with the DefaultMaxKeyAge, which does not flag keys with an unknown
+// creation time
+func NewKeyAgePolicy() *KeyAgePolicy {
+	return &KeyAgePolicy{maxAge: DefaultMaxKeyAge, now: time.Now}
+}
+
+// WithMaxAge is a fluent method for setting the maximum age of an access key
+func (p *KeyAgePolicy) WithMaxAge(d time.Duration) *KeyAgePolicy {
+	p.maxAge = d
+	return p
+}
+
+// WithRequireCreated is a fluent method for setting if keys without a creation time are a policy violation
+func (p *KeyAgePolicy) WithRequireCreated(b bool) *KeyAgePolicy {
+	p.requireCreated = b
+	return p
+}
+
+// KeyAge is the age information of the access key for a profile in the credentials file
+type KeyAge struct {
+	// Profile is the profile name
+	Profile string `json:"profile" yaml:"profile"`
+	// AccessKeyId is the access key of the profile
+	AccessKeyId string `json:"access_key_id" yaml:"access_key_id"`
+	// Created is the creation time of the access key, or nil if unknown
+	Created *time.Time `json:"created,omitempty" yaml:"created,omitempty"`
+	// Age is the age of the access key, in whole days
+	Age int `json:"age_days" yaml:"age_days"`
+	// Violation is the reason the key does not comply with the KeyAgePolicy, or empty if it complies
+	Violation string `json:"violation,omitempty" yaml:"violation,omitempty"`
+}
+
+// check sets the Age and Violation attributes of the KeyAge
+func (p *KeyAgePolicy) check(k *KeyAge) {
+	if k.Created == nil {
+		if p.requireCreated {
+			k.Violation = "access key creation time unknown"
+		}
+		return
+	}
+
+	age := p.now().Sub(*k.Created)
+	k.Age = int(age.Hours() / 24)
+
+	if age > p.maxAge {
+		k.Violation = fmt.Sprintf("access key is older than %d days", int(p.maxAge.Hours()/24))
+	}
+}
+
+// KeyCreatedAt returns the creation time of the access key for the profile, found in the x_access_key_created
+// attribute of the profile.  If the attribute is not set, the zero time is returned.  Profile name resolution is the
+// same as the Credentials() method.
+func (p *IniCredentialProvider) KeyCreatedAt(profile ...string) (time.Time, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pr, err := p.profile(firstOrEmpty(profile), nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return keyCreated(pr)
+}
+
+// KeyAges reports the age of the access key for each profile in the credentials file containing static (non-session)
+// credentials, checked against the KeyAgePolicy.  If the policy is nil, a default policy (NewKeyAgePolicy()) is used.
+// The returned entries are sorted by profile name.
+func (p *IniCredentialProvider) KeyAges(policy *KeyAgePolicy) []KeyAge {
+	if policy == nil {
+		policy = NewKeyAgePolicy()
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ages := make([]KeyAge, 0)
+	for _, s := range p.Sections() {
+		attrs := s.KeysHash()
+		if !p.isProfileSection(s.Name()) || len(attrs["aws_access_key_id"]) < 1 || len(attrs["aws_session_token"]) > 0 {
+			continue
+		}
+
+		k := KeyAge{Profile: strings.TrimPrefix(s.Name(), "profile "), AccessKeyId: attrs["aws_access_key_id"]}
+		if t, err := keyCreated(s); err != nil {
+			k.Violation = fmt.Sprintf("invalid %s value: %v", KeyCreatedAttribute, err)
+		} else if !t.IsZero() {
+			k.Created = &t
+		}
+
+		if len(k.Violation) < 1 {
+			policy.check(&k)
+		}
+		ages = append(ages, k)
+	}
+
+	sort.SliceStable(ages, func(i, j int) bool {
+		return ages[i].Profile < ages[j].Profile
+	})
+	return ages
+}
+
+// keyCreated returns the value of the x_access_key_created attribute of the profile section, or the zero time
+func keyCreated(s *ini.Section) (time.Time, error) {
+	v := s.KeysHash()[KeyCreatedAttribute]
+	if len(v) < 1 {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// setKeyCreated updates the x_access_key_created attribute of the profile section.  Session credentials do not have
+// a meaningful key age, so the attribute is removed if the credentials contain a session token.  Otherwise, if the
+// creds are an iam.AccessKey with a CreateDate, that time is used, or the current time if the access key changed.
+func setKeyCreated(s *ini.Section, oldKey string, c *awsCredentials, creds interface{}) {
+	if len(c.SessionToken) > 0 {
+		s.DeleteKey(KeyCreatedAttribute)
+		return
+	}
+
+	var t time.Time
+	switch k := creds.(type) {
+	case iam.AccessKey:
+		if k.CreateDate != nil {
+			t = *k.CreateDate
+		}
+	case *iam.AccessKey:
+		if k.CreateDate != nil {
+			t = *k.CreateDate
+		}
+	}
+
+	if t.IsZero() {
+		if oldKey == c.AccessKey {
+			return
+		}
+		t = time.Now()
+	}
+
+	s.Key(KeyCreatedAttribute).SetValue(t.UTC().Format(time.RFC3339))
+}
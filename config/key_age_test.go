@@ -0,0 +1,173 @@
+package config
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"testing"
+	"time"
+)
+
+var keyAgeCreds = []byte(`[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = secret
+x_access_key_created = 2020-01-01T00:00:00Z
+
+[new]
+aws_access_key_id = AKIANEW
+aws_secret_access_key = secret
+x_access_key_created = 2020-03-15T00:00:00Z
+
+[unknown]
+aws_access_key_id = AKIAUNKNOWN
+aws_secret_access_key = secret
+
+[bad]
+aws_access_key_id = AKIABAD
+aws_secret_access_key = secret
+x_access_key_created = yesterday
+
+[session]
+aws_access_key_id = ASIASESSION
+aws_secret_access_key = secret
+aws_session_token = token
+`)
+
+func TestIniCredentialProvider_KeyAges(t *testing.T) {
+	p, err := NewIniCredentialProvider(keyAgeCreds)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	now := func() time.Time { return time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("default policy", func(t *testing.T) {
+		policy := NewKeyAgePolicy()
+		policy.now = now
+
+		ages := p.KeyAges(policy)
+		if len(ages) != 4 {
+			t.Errorf("unexpected key count: %+v", ages)
+			return
+		}
+
+		expected := map[string][2]interface{}{
+			"bad":     {0, "invalid x_access_key_created value: parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\""},
+			"default": {91, "access key is older than 90 days"},
+			"new":     {17, ""},
+			"unknown": {0, ""},
+		}
+
+		for _, a := range ages {
+			e := expected[a.Profile]
+			if a.Age != e[0] || a.Violation != e[1] {
+				t.Errorf("unexpected age for %s: %+v", a.Profile, a)
+			}
+		}
+	})
+
+	t.Run("custom policy", func(t *testing.T) {
+		policy := NewKeyAgePolicy().WithMaxAge(7 * 24 * time.Hour).WithRequireCreated(true)
+		policy.now = now
+
+		n := 0
+		for _, a := range p.KeyAges(policy) {
+			if len(a.Violation) > 0 {
+				n++
+			}
+		}
+
+		if n != 4 {
+			t.Errorf("expected 4 violations, found %d", n)
+		}
+	})
+
+	t.Run("created at", func(t *testing.T) {
+		c, err := p.KeyCreatedAt("new")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !c.Equal(time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected creation time: %v", c)
+		}
+
+		if c, _ := p.KeyCreatedAt("unknown"); !c.IsZero() {
+			t.Errorf("unexpected creation time: %v", c)
+		}
+	})
+}
+
+func TestIniCredentialProvider_UpdateCredentials_KeyCreated(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		p, err := NewIniCredentialProvider(keyAgeCreds)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.UpdateCredentials("unknown", credentials.Value{AccessKeyID: "AKIACHANGED", SecretAccessKey: "s"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if s, _ := p.Profile("unknown"); s.HasKey(KeyCreatedAttribute) {
+			t.Errorf("unexpected %s attribute", KeyCreatedAttribute)
+		}
+	})
+
+	p, err := NewIniCredentialProvider(keyAgeCreds, WithKeyCreatedTracking(true))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("iam key", func(t *testing.T) {
+		d := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+		k := iam.AccessKey{AccessKeyId: aws.String("AKIAIAM"), SecretAccessKey: aws.String("s"),
+			Status: aws.String(iam.StatusTypeActive), CreateDate: &d}
+
+		if err := p.UpdateCredentials("new", k); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c, _ := p.KeyCreatedAt("new"); !c.Equal(d) {
+			t.Errorf("unexpected creation time: %v", c)
+		}
+	})
+
+	t.Run("same key", func(t *testing.T) {
+		if err := p.UpdateCredentials("default", credentials.Value{AccessKeyID: "AKIADEFAULT", SecretAccessKey: "s"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c, _ := p.KeyCreatedAt("default"); !c.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("creation time changed: %v", c)
+		}
+	})
+
+	t.Run("new key", func(t *testing.T) {
+		if err := p.UpdateCredentials("unknown", credentials.Value{AccessKeyID: "AKIACHANGED", SecretAccessKey: "s"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c, _ := p.KeyCreatedAt("unknown"); time.Since(c) > time.Minute {
+			t.Errorf("unexpected creation time: %v", c)
+		}
+	})
+
+	t.Run("session", func(t *testing.T) {
+		v := credentials.Value{AccessKeyID: "ASIATEMP", SecretAccessKey: "s", SessionToken: "t"}
+		if err := p.UpdateCredentials("default", v); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c, _ := p.KeyCreatedAt("default"); !c.IsZero() {
+			t.Errorf("unexpected creation time: %v", c)
+		}
+	})
+}
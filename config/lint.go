@@ -0,0 +1,362 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/go-ini/ini"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity is the level of importance of a lint Diagnostic
+type Severity string
+
+const (
+	// SeverityError is a problem which will cause the AWS SDK or CLI to fail when using the profile
+	SeverityError Severity = "error"
+	// SeverityWarning is a problem which may cause unexpected behavior when using the profile
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a single problem found while linting a config or credentials source.  File is the name of the source
+// the line number refers to (a file path or url), which is empty for []byte and io.Reader sources.
+type Diagnostic struct {
+	Severity  Severity `json:"severity" yaml:"severity"`
+	File      string   `json:"file,omitempty" yaml:"file,omitempty"`
+	Profile   string   `json:"profile,omitempty" yaml:"profile,omitempty"`
+	Attribute string   `json:"attribute,omitempty" yaml:"attribute,omitempty"`
+	Line      int      `json:"line,omitempty" yaml:"line,omitempty"`
+	Message   string   `json:"message" yaml:"message"`
+}
+
+// String returns the diagnostic formatted in the style of a compiler error message
+func (d Diagnostic) String() string {
+	var b strings.Builder
+
+	if len(d.File) > 0 {
+		b.WriteString(fmt.Sprintf("%s:", d.File))
+	}
+
+	if d.Line > 0 {
+		b.WriteString(fmt.Sprintf("%d: ", d.Line))
+	}
+	b.WriteString(fmt.Sprintf("%s: ", d.Severity))
+
+	if len(d.Profile) > 0 {
+		b.WriteString(fmt.Sprintf("[%s] ", d.Profile))
+	}
+
+	if len(d.Attribute) > 0 {
+		b.WriteString(fmt.Sprintf("%s: ", d.Attribute))
+	}
+	b.WriteString(d.Message)
+
+	return b.String()
+}
+
+var credentialSources = []string{"Environment", "Ec2InstanceMetadata", "EcsContainer"}
+
+// Lint checks the config file for problems which will prevent the AWS SDK or CLI from using a profile, or are likely to
+// cause unexpected behavior.  If credential providers are supplied, the source_profile of a role profile can also be
+// found in the credentials file, otherwise source_profile values not found in the config file are only a warning.
+// Line numbers are in the source (the file itself, or an included file) defining the profile or attribute.  The
+// returned diagnostics are sorted by source and line number.
+func (p *IniConfigProvider) Lint(creds ...*IniCredentialProvider) []Diagnostic {
+	d := make([]Diagnostic, 0)
+	seen := make(map[string]string)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, s := range p.Sections() {
+		if !p.isProfileSection(s.Name()) {
+			continue
+		}
+
+		n := strings.TrimPrefix(s.Name(), "profile ")
+		file, line := p.sectionLine(s.Name())
+
+		if s.Name() != DefaultProfileName && !strings.HasPrefix(s.Name(), "profile ") {
+			d = append(d, Diagnostic{Severity: SeverityWarning, Profile: n, File: file, Line: line,
+				Message: fmt.Sprintf("section [%s] should be named [profile %s]", s.Name(), n)})
+		}
+
+		if o, ok := seen[n]; ok {
+			d = append(d, Diagnostic{Severity: SeverityWarning, Profile: n, File: file, Line: line,
+				Message: fmt.Sprintf("profile is also defined as [%s]", o)})
+		}
+		seen[n] = s.Name()
+
+		d = append(d, p.lintProfile(n, s, creds)...)
+	}
+
+	sortDiagnostics(d)
+	return d
+}
+
+func (p *IniConfigProvider) lintProfile(name string, s *ini.Section, creds []*IniCredentialProvider) []Diagnostic {
+	d := make([]Diagnostic, 0)
+	attrs := s.KeysHash()
+	diag := func(sev Severity, attr, msg string) {
+		file, line := p.keyLine(s.Name(), attr)
+		if line < 1 {
+			file, line = p.sectionLine(s.Name())
+		}
+		d = append(d, Diagnostic{Severity: sev, Profile: name, Attribute: attr, File: file, Line: line, Message: msg})
+	}
+
+	src, hasSrc := attrs["source_profile"]
+	credSrc, hasCredSrc := attrs["credential_source"]
+
+	// an empty or "0" value in a profile using x_inherit clears the inherited value, and isn't validated
+	_, inherits := attrs[InheritAttribute]
+	clears := func(v string) bool {
+		return inherits && (len(v) < 1 || v == "0")
+	}
+
+	if v, ok := attrs["role_arn"]; ok && !clears(v) {
+		if err := validateRoleArn(v); err != nil && !hasVariables(v) {
+			diag(SeverityError, "role_arn", fmt.Sprintf("invalid value '%s': %v", v, err))
+		}
+	}
+
+	// the role requirements are met by the profile's attributes, or the attributes of the profiles it inherits
+	inherited := p.inheritedAttributes(s)
+	has := func(k string) bool {
+		v, ok := inherited[k]
+		return ok && !clears(v)
+	}
+
+	if has("role_arn") && !has("source_profile") && !has("credential_source") && len(inherited["web_identity_token_file"]) < 1 {
+		diag(SeverityError, "role_arn", "role profile requires one of source_profile, credential_source, or web_identity_token_file")
+	}
+
+	if hasSrc && hasCredSrc {
+		diag(SeverityError, "credential_source", "credential_source and source_profile are mutually exclusive")
+	}
+
+	if hasSrc && src != name {
+		if _, err := p.configProfile(src); err != nil {
+			sev := SeverityWarning
+			msg := fmt.Sprintf("profile '%s' not found in config file", src)
+
+			if len(creds) > 0 {
+				sev = SeverityError
+				msg = fmt.Sprintf("profile '%s' not found in config or credentials file", src)
+
+				for _, c := range creds {
+					if c.hasSection(src) {
+						sev = ""
+						break
+					}
+				}
+			}
+
+			if len(sev) > 0 {
+				diag(sev, "source_profile", msg)
+			}
+		}
+	}
+
+	if hasSrc {
+		err := checkSourceProfiles(name, src, func(n string) (string, bool) {
+			s, err := p.configProfile(n)
+			if err != nil {
+				return "", false
+			}
+			return s.KeysHash()["source_profile"], true
+		})
+		if err != nil {
+			diag(SeverityError, "source_profile", err.Error())
+		}
+	}
+
+	if hasCredSrc && !stringInSlice(credSrc, credentialSources) {
+		diag(SeverityError, "credential_source",
+			fmt.Sprintf("invalid value '%s', must be one of %s", credSrc, strings.Join(credentialSources, ", ")))
+	}
+
+	if v, ok := attrs["mfa_serial"]; ok && !hasVariables(v) && !clears(v) {
+		if err := validateMfaSerial(v); err != nil {
+			diag(SeverityError, "mfa_serial", fmt.Sprintf("invalid value '%s': %v", v, err))
+		}
+	}
+
+	// the SDK region table may be older than the region list, so this is only a warning
+	if v, ok := attrs["region"]; ok && !hasVariables(v) && !clears(v) {
+		if err := validateRegion(v); err != nil {
+			diag(SeverityWarning, "region", fmt.Sprintf("invalid value '%s': %v", v, err))
+		}
+	}
+
+	if v, ok := attrs["duration_seconds"]; ok && !hasVariables(v) && !clears(v) {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			diag(SeverityError, "duration_seconds", fmt.Sprintf("invalid integer value '%s'", v))
+		} else if err := validateDuration(i); err != nil {
+			diag(SeverityError, "duration_seconds", fmt.Sprintf("invalid value %d: %v", i, err))
+		}
+	}
+
+	if v, ok := attrs[InheritAttribute]; ok {
+		chain := []string{name}
+		seen := map[string]bool{name: true}
+
+		for len(v) > 0 {
+			chain = append(chain, v)
+			if seen[v] {
+				diag(SeverityError, InheritAttribute, (&InheritCycleError{Chain: chain}).Error())
+				break
+			}
+			seen[v] = true
+
+			s, err := p.configProfile(v)
+			if err != nil {
+				diag(SeverityError, InheritAttribute, fmt.Sprintf("profile '%s' not found in config file", v))
+				break
+			}
+			v = s.KeysHash()[InheritAttribute]
+		}
+	}
+
+	// the AWS CLI will use credentials in the config file, but other tools won't find them, and secrets don't belong here
+	for _, k := range credentialAttributes {
+		if _, ok := attrs[k]; ok {
+			diag(SeverityWarning, k, "credentials found in config file, these belong in the credentials file")
+		}
+	}
+
+	return d
+}
+
+// inheritedAttributes returns the attributes of the profile section merged with the attributes of the profiles it
+// inherits from, where attributes set in the profile (even if empty) override the inherited values.  The chain ends at a
+// profile which is not found, or is already in the chain.  The caller is expected to hold the mutex.
+func (p *IniConfigProvider) inheritedAttributes(s *ini.Section) map[string]string {
+	chain := []map[string]string{s.KeysHash()}
+	seen := map[string]bool{s.Name(): true}
+
+	for v := chain[0][InheritAttribute]; len(v) > 0; v = chain[0][InheritAttribute] {
+		x, err := p.configProfile(v)
+		if err != nil || seen[x.Name()] {
+			break
+		}
+		seen[x.Name()] = true
+		chain = append([]map[string]string{x.KeysHash()}, chain...)
+	}
+
+	m := make(map[string]string)
+	for _, c := range chain {
+		for k, v := range c {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// Lint checks the credentials file for problems which will prevent the AWS SDK or CLI from using the credentials.
+// The returned diagnostics are sorted by source and line number.
+func (p *IniCredentialProvider) Lint() []Diagnostic {
+	d := make([]Diagnostic, 0)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, s := range p.Sections() {
+		if !p.isProfileSection(s.Name()) {
+			continue
+		}
+
+		n := strings.TrimPrefix(s.Name(), "profile ")
+		file, line := p.sectionLine(s.Name())
+
+		if strings.HasPrefix(s.Name(), "profile ") {
+			d = append(d, Diagnostic{Severity: SeverityWarning, Profile: n, File: file, Line: line,
+				Message: fmt.Sprintf("section [%s] should be named [%s] in the credentials file", s.Name(), n)})
+		}
+
+		ak := s.HasKey("aws_access_key_id")
+		sk := s.HasKey("aws_secret_access_key") || s.HasKey(SecretRefAttribute)
+		if ak != sk || (!ak && s.HasKey("aws_session_token")) {
+			d = append(d, Diagnostic{Severity: SeverityError, Profile: n, File: file, Line: line,
+				Message: "incomplete credentials, missing access key and/or secret key"})
+		}
+	}
+
+	sortDiagnostics(d)
+	return d
+}
+
+func stringInSlice(s string, l []string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortDiagnostics(d []Diagnostic) {
+	sort.SliceStable(d, func(i, j int) bool {
+		if d[i].File != d[j].File {
+			return d[i].File < d[j].File
+		}
+		return d[i].Line < d[j].Line
+	})
+}
+
+// lineIndex records the line numbers of the section headers and keys found in raw INI data
+type lineIndex struct {
+	sections map[string]int
+	keys     map[string]map[string]int
+}
+
+func newLineIndex(raw []byte) *lineIndex {
+	idx := &lineIndex{sections: make(map[string]int), keys: make(map[string]map[string]int)}
+	sec := ini.DefaultSection
+
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	for n := 1; sc.Scan(); n++ {
+		l := strings.TrimSpace(sc.Text())
+
+		switch {
+		case len(l) < 1 || l[0] == '#' || l[0] == ';':
+			continue
+		case l[0] == '[':
+			if i := strings.LastIndex(l, "]"); i > 0 {
+				sec = strings.TrimSpace(l[1:i])
+				if _, ok := idx.sections[sec]; !ok {
+					idx.sections[sec] = n
+				}
+			}
+		default:
+			if i := strings.IndexAny(l, "=:"); i > 0 {
+				k := strings.TrimSpace(l[:i])
+				if _, ok := idx.keys[sec]; !ok {
+					idx.keys[sec] = make(map[string]int)
+				}
+				idx.keys[sec][k] = n
+			}
+		}
+	}
+
+	return idx
+}
+
+// section returns the line number of the section header, or 0 if not found (or the index is nil)
+func (i *lineIndex) section(name string) int {
+	if i == nil {
+		return 0
+	}
+	return i.sections[name]
+}
+
+// key returns the line number of the key in the section, or 0 if not found (or the index is nil)
+func (i *lineIndex) key(section, name string) int {
+	if i == nil {
+		return 0
+	}
+	return i.keys[section][name]
+}
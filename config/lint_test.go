@@ -0,0 +1,229 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var lintConfig = []byte(`[default]
+region = us-east-1
+
+[profile good]
+role_arn = arn:aws:iam::123456789012:role/Admin
+source_profile = default
+mfa_serial = arn:aws:iam::123456789012:mfa/user
+duration_seconds = 3600
+
+[profile no-source]
+role_arn = arn:aws:iam::123456789012:role/Admin
+
+[profile bad-source]
+role_arn = arn:aws:iam::123456789012:role/Admin
+source_profile = nowhere
+
+[profile creds-source]
+role_arn = arn:aws:iam::123456789012:role/Admin
+source_profile = creds-only
+
+[profile bad-arn]
+role_arn = arn:aws::iam:role/Admin
+credential_source = Environment
+
+[profile bad-duration]
+duration_seconds = 60
+
+[profile both-sources]
+role_arn = arn:aws:iam::123456789012:role/Admin
+source_profile = default
+credential_source = Nope
+
+[uncommon]
+region = eu-west-1
+
+[profile uncommon]
+region = eu-west-1
+`)
+
+func TestIniConfigProvider_Lint(t *testing.T) {
+	p, err := NewIniConfigProvider(lintConfig)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	find := func(d []Diagnostic, profile, attr string) []Diagnostic {
+		r := make([]Diagnostic, 0)
+		for _, x := range d {
+			if x.Profile == profile && x.Attribute == attr {
+				r = append(r, x)
+			}
+		}
+		return r
+	}
+
+	d := p.Lint()
+	for i := 1; i < len(d); i++ {
+		if d[i-1].Line > d[i].Line {
+			t.Error("diagnostics not sorted")
+		}
+	}
+
+	t.Run("good", func(t *testing.T) {
+		if len(find(d, "good", "")) > 0 || len(find(d, "good", "role_arn")) > 0 {
+			t.Error("unexpected diagnostics for good profile")
+		}
+	})
+
+	t.Run("no source", func(t *testing.T) {
+		x := find(d, "no-source", "role_arn")
+		if len(x) != 1 || x[0].Severity != SeverityError || x[0].Line != 11 {
+			t.Errorf("unexpected diagnostics: %v", x)
+		}
+	})
+
+	t.Run("bad source", func(t *testing.T) {
+		x := find(d, "bad-source", "source_profile")
+		if len(x) != 1 || x[0].Severity != SeverityWarning || x[0].Line != 15 {
+			t.Errorf("unexpected diagnostics: %v", x)
+		}
+	})
+
+	t.Run("bad arn", func(t *testing.T) {
+		x := find(d, "bad-arn", "role_arn")
+		if len(x) != 1 || x[0].Severity != SeverityError || x[0].Line != 22 {
+			t.Errorf("unexpected diagnostics: %v", x)
+		}
+	})
+
+	t.Run("bad duration", func(t *testing.T) {
+		x := find(d, "bad-duration", "duration_seconds")
+		if len(x) != 1 || x[0].Severity != SeverityError || x[0].Line != 26 {
+			t.Errorf("unexpected diagnostics: %v", x)
+		}
+	})
+
+	t.Run("both sources", func(t *testing.T) {
+		if x := find(d, "both-sources", "credential_source"); len(x) != 2 {
+			t.Errorf("unexpected diagnostics: %v", x)
+		}
+	})
+
+	t.Run("naming", func(t *testing.T) {
+		x := find(d, "uncommon", "")
+		if len(x) != 2 || x[0].Line != 33 || x[1].Line != 36 {
+			t.Errorf("unexpected diagnostics: %v", x)
+		}
+	})
+
+	t.Run("plaintext credentials", func(t *testing.T) {
+		p, err := NewIniConfigProvider(credentialsConfig)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		d := p.Lint()
+		if x := find(d, "default", "aws_secret_access_key"); len(x) != 1 || x[0].Severity != SeverityWarning || x[0].Line != 4 {
+			t.Errorf("unexpected diagnostics: %v", x)
+		}
+
+		if x := find(d, "clean", ""); len(x) > 0 {
+			t.Errorf("unexpected diagnostics: %v", x)
+		}
+	})
+
+	t.Run("with credentials", func(t *testing.T) {
+		c, err := NewIniCredentialProvider([]byte("[creds-only]\naws_access_key_id = AKIAMOCK\naws_secret_access_key = MockSecret"))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		d := p.Lint(c)
+		if x := find(d, "creds-source", "source_profile"); len(x) > 0 {
+			t.Errorf("unexpected diagnostics: %v", x)
+		}
+
+		if x := find(d, "bad-source", "source_profile"); len(x) != 1 || x[0].Severity != SeverityError {
+			t.Errorf("unexpected diagnostics: %v", x)
+		}
+	})
+}
+
+func TestIniConfigProvider_LintIncludes(t *testing.T) {
+	d, err := ioutil.TempDir("", "aws-config-lint-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	inc, main := filepath.Join(d, "included"), filepath.Join(d, "config")
+	if err := ioutil.WriteFile(inc, []byte("# included profiles\n\n[profile inc]\nrole_arn = arn:aws::iam:role/Admin\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(main, []byte("[include]\ninc = included\n\n[profile inc]\nduration_seconds = 60\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewIniConfigProvider(main)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diags := p.Lint()
+	if len(diags) < 2 {
+		t.Fatalf("missing diagnostics: %v", diags)
+	}
+
+	for _, x := range diags {
+		switch x.Attribute {
+		case "role_arn":
+			if x.File != inc || x.Line != 4 {
+				t.Errorf("unexpected location: %v", x)
+			}
+		case "duration_seconds":
+			if x.File != main || x.Line != 5 {
+				t.Errorf("unexpected location: %v", x)
+			}
+		default:
+			t.Errorf("unexpected diagnostic: %v", x)
+		}
+	}
+}
+
+func TestIniCredentialProvider_Lint(t *testing.T) {
+	p, err := NewIniCredentialProvider(credFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer p.Close()
+
+	d := p.Lint()
+	if len(d) != 2 {
+		t.Errorf("unexpected diagnostics: %v", d)
+		return
+	}
+
+	for _, x := range d {
+		if x.Severity != SeverityError || x.Line < 1 {
+			t.Errorf("unexpected diagnostic: %v", x)
+		}
+	}
+}
+
+func TestDiagnostic_String(t *testing.T) {
+	d := Diagnostic{Severity: SeverityError, Profile: "p", Attribute: "role_arn", Line: 3, Message: "bad"}
+	if s := d.String(); !strings.HasPrefix(s, "3: error: [p] role_arn: bad") {
+		t.Errorf("unexpected string: %s", s)
+	}
+
+	d.File = "config"
+	if s := d.String(); !strings.HasPrefix(s, "config:3: error: [p] role_arn: bad") {
+		t.Errorf("unexpected string: %s", s)
+	}
+}
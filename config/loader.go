@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/go-ini/ini"
 	"io"
@@ -8,7 +9,9 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 )
 
 const (
@@ -21,15 +24,57 @@ const (
 // DefaultProfileName is the name of the default section in the config file
 var DefaultProfileName = strings.ToLower(ini.DefaultSection)
 
+// awsConfigFile is the ini file data shared by the ini providers.  Methods which read or modify the data hold the mutex,
+// so a provider is safe for concurrent use.  The *ini.Section values returned from the Profile() methods are not
+// protected once returned, callers modifying them concurrently with other operations must provide their own locking.
 type awsConfigFile struct {
 	*ini.File
-	Path   string
-	isTemp bool
+	Path     string
+	isTemp   bool
+	raw      []byte
+	policy   *ProfilePolicy
+	log      Logger
+	enc      *encryption
+	doc      *document
+	prov     *provenance
+	includes bool
+	mu       sync.RWMutex
 }
 
-func load(source interface{}, def func(f *awsConfigFile)) (*awsConfigFile, error) {
-	f := new(awsConfigFile)
+func load(source interface{}, def func(f *awsConfigFile), opts ...Option) (*awsConfigFile, error) {
+	o := newOptions(opts)
+	f := &awsConfigFile{log: o.log, includes: o.includes}
 
+	srcs, err := f.sources(source, def, o)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// keep a copy of the raw data of the file being written, so changes are written using the layout of the source
+	f.raw = srcs[len(srcs)-1].raw
+
+	// later sources override the attributes of earlier sources
+	data := make([]interface{}, len(srcs))
+	for i, x := range srcs {
+		data[i] = x.raw
+	}
+
+	s, err := ini.Load(data[0], data[1:]...)
+	if err != nil {
+		f.Close()
+		return nil, newParseError(f.Path, f.raw, err)
+	}
+	f.File = s
+	f.doc = newDocument(f.raw, s)
+	f.prov = newProvenance(srcs)
+
+	f.logger().Debug("loaded ini data", "path", f.Path, "bytes", len(f.raw), "sections", len(s.Sections()), "sources", len(srcs))
+	return f, nil
+}
+
+// read returns the data from the source, setting the Path attribute if the source is a file or url
+func (f *awsConfigFile) read(source interface{}, def func(f *awsConfigFile)) ([]byte, error) {
 	switch t := source.(type) {
 	case string:
 		// path to local file, or url (file and http(s) supported)
@@ -52,10 +97,6 @@ func load(source interface{}, def func(f *awsConfigFile)) (*awsConfigFile, error
 		f.Path = t.Name()
 		f.isTemp = false
 	case io.Reader:
-		// other kind of reader (ensure it's a ReadCloser here so it's supported by go-ini)
-		if _, ok := t.(io.ReadCloser); !ok {
-			source = ioutil.NopCloser(t)
-		}
 		f.isTemp = false
 	default:
 		source = []byte("[default]")
@@ -68,25 +109,35 @@ func load(source interface{}, def func(f *awsConfigFile)) (*awsConfigFile, error
 		if len(f.Path) > 0 {
 			if _, err := os.Stat(f.Path); err == nil {
 				source = f.Path
+			} else {
+				f.logger().Debug("default file not found, using empty default profile", "path", f.Path)
 			}
 		}
 	}
 
-	s, err := ini.Load(source)
-	if err != nil {
-		return nil, err
-	}
-	f.File = s
+	return readSource(source)
+}
 
-	return f, nil
+func readSource(source interface{}) ([]byte, error) {
+	switch t := source.(type) {
+	case string:
+		return ioutil.ReadFile(t)
+	case []byte:
+		return t, nil
+	case io.Reader:
+		return ioutil.ReadAll(t)
+	}
+	return nil, fmt.Errorf("unsupported source type %T", source)
 }
 
 func (f *awsConfigFile) ProfileStrings() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
 	s := make([]string, 0)
-	fmt.Printf("%+v\n", f.SectionStrings())
 	for _, v := range f.SectionStrings() {
-		// Skip the go-ini DEFAULT section
-		if v != ini.DefaultSection {
+		// Skip the go-ini DEFAULT section, and the include section
+		if f.isProfileSection(v) {
 			s = append(s, strings.TrimPrefix(v, "profile "))
 		}
 	}
@@ -97,24 +148,155 @@ func (f *awsConfigFile) ProfileStrings() []string {
 // Since there is no explicit format of the profile data (the cli/sdk defines some values, but allows custom attributes),
 // the returned value is the ini file section data, where the caller will be able to process the profile attributes locally.
 func (f *awsConfigFile) Profile(name string) (*ini.Section, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
 	return f.profile(name, nil)
 }
 
-// Attempt to fetch the given profile name.  If an empty string is passed, check env vars, or provide the default name.
+// Attempt to fetch the given profile name.  If an empty string is passed, the name is selected using the file's
+// ProfilePolicy (see ProfilePolicy.Resolve()).
+// The caller is expected to hold the mutex.
 // An optional function can be provided as a handler to return an alternate profile name, in case the original name is
 // not found.  This should satisfy the oddity that is the AWS config file where non-default profiles should be prefixed
 // with "profile" in the name.
 func (f *awsConfigFile) profile(name string, nfh func(n string) string) (*ini.Section, error) {
-	name = ResolveProfile(&name)
+	name = policyOrDefault(f.policy).Resolve(name)
+	f.logger().Debug("profile lookup", "profile", name, "path", f.Path)
 
 	s, err := f.GetSection(name)
+	if err != nil && nfh != nil {
+		alt := nfh(name)
+		f.logger().Debug("profile not found, trying alternate section name", "profile", name, "section", alt)
+		s, err = f.GetSection(alt)
+	}
+
 	if err != nil {
-		if nfh != nil {
-			return f.GetSection(nfh(name))
+		return nil, &ProfileNotFoundError{Profile: name}
+	}
+	return s, nil
+}
+
+// WriteTo writes the file data to the io.Writer, holding the read lock so updates made by other goroutines are not
+// written partially.  The data is written in the layout of the source data, so only the lines for changed attributes
+// differ from the source, and comments and formatting are preserved.
+func (f *awsConfigFile) WriteTo(w io.Writer) (int64, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.writeTo(w)
+}
+
+// writeTo writes the file data to the io.Writer, encrypting it if the file uses encryption.  The caller is expected
+// to hold the mutex.
+func (f *awsConfigFile) writeTo(w io.Writer) (int64, error) {
+	data, err := f.render()
+	if err != nil {
+		return 0, err
+	}
+
+	if f.enc != nil {
+		if data, err = sealWithKey(encryptedFileMagic, f.enc.key, f.enc.salt, data); err != nil {
+			return 0, err
 		}
+	}
+
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// render returns the plaintext ini data, laid out using the source document.  The caller is expected to hold the mutex.
+func (f *awsConfigFile) render() ([]byte, error) {
+	if f.doc != nil {
+		return f.doc.render(f.File), nil
+	}
+
+	b := new(bytes.Buffer)
+	if _, err := f.File.WriteTo(b); err != nil {
 		return nil, err
 	}
-	return s, nil
+	return b.Bytes(), nil
+}
+
+// SaveTo writes the file data to the named file, holding the read lock so updates made by other goroutines are not
+// written partially.  Encrypted files are written atomically, and readable only by the owner if newly created.
+func (f *awsConfigFile) SaveTo(filename string) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.enc == nil {
+		data, err := f.render()
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filename, data, 0666)
+	}
+
+	return writeAtomic(filename, func(w io.Writer) error {
+		_, err := f.writeTo(w)
+		return err
+	})
+}
+
+// saveAtomic writes the file data to a temporary file in the same directory as the named file, and renames it to the
+// named file, so readers of the file will never see partially written data.  The permissions of an existing file are
+// preserved, new files are created readable only by the owner.
+func (f *awsConfigFile) saveAtomic(filename string) error {
+	return writeAtomic(filename, func(w io.Writer) error {
+		_, err := f.WriteTo(w)
+		return err
+	})
+}
+
+// writeAtomic writes the data to the named file using a temporary file and rename.  See awsConfigFile.saveAtomic()
+func writeAtomic(filename string, write func(w io.Writer) error) error {
+	mode := os.FileMode(0600)
+	if fi, err := os.Stat(filename); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	tf, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tf.Name())
+
+	if err := write(tf); err != nil {
+		tf.Close()
+		return err
+	}
+
+	if err := tf.Sync(); err != nil {
+		tf.Close()
+		return err
+	}
+
+	if err := tf.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tf.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tf.Name(), filename)
+}
+
+// logger returns the file's Logger, or a Logger discarding all events if one isn't set
+func (f *awsConfigFile) logger() Logger {
+	return loggerOrNop(f.log)
+}
+
+// hasSection returns true if the file contains the named section
+func (f *awsConfigFile) hasSection(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	_, err := f.GetSection(name)
+	return err == nil
+}
+
+// Writable returns true if the data was loaded from a local file, so SaveTo() is able to write changes back to the
+// source using the Path attribute.  Data loaded from a url, []byte, or io.Reader source is not writable.
+func (f *awsConfigFile) Writable() bool {
+	return len(f.Path) > 0 && !f.isTemp
 }
 
 func (f *awsConfigFile) Close() error {
@@ -127,6 +309,7 @@ func (f *awsConfigFile) Close() error {
 func (f *awsConfigFile) urlHandler(u *url.URL) error {
 	switch u.Scheme {
 	case "http", "https":
+		f.logger().Debug("fetching http source", "url", u.String())
 		tf, err := fetchHttpSource(u)
 		if err != nil {
 			return err
@@ -141,7 +324,7 @@ func (f *awsConfigFile) urlHandler(u *url.URL) error {
 		f.isTemp = false
 	default:
 		// error: not supported
-		return fmt.Errorf("url scheme '%s' not supported", u.Scheme)
+		return &UnsupportedSchemeError{Scheme: u.Scheme}
 	}
 	return nil
 }
@@ -157,21 +340,24 @@ func fetchHttpSource(u *url.URL) (*os.File, error) {
 		return nil, fmt.Errorf("HTTP Response Code %d", r.StatusCode)
 	}
 
-	return ioutil.TempFile("", "AwsConfigLoader-")
+	f, err := ioutil.TempFile("", "AwsConfigLoader-")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r.Body); err != nil {
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
 }
 
-// ResolveProfile is a helper method to check the env vars for a profile name if the provided argument is nil or empty
+// ResolveProfile is a helper method to check the env vars for a profile name if the provided argument is nil or empty,
+// using the rules of the DefaultProfilePolicy
 func ResolveProfile(p *string) string {
-	if p == nil || len(*p) < 1 {
-		var n string
-		var ok bool
-		if n, ok = os.LookupEnv(ProfileEnvVar); !ok {
-			if n, ok = os.LookupEnv(DefaultProfileEnvVar); !ok {
-				n = DefaultProfileName
-			}
-		}
-		return n
+	if p == nil {
+		return DefaultProfilePolicy.Resolve("")
 	}
-
-	return *p
+	return DefaultProfilePolicy.Resolve(*p)
 }
@@ -3,6 +3,7 @@ package config
 import (
 	"bytes"
 	"context"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -23,6 +24,10 @@ func TestLoad(t *testing.T) {
 				t.Error("file name mismatch")
 			}
 
+			if !c.Writable() {
+				t.Error("file source is not writable")
+			}
+
 			if len(c.Sections()) < 1 {
 				t.Error("missing section data")
 			}
@@ -53,9 +58,16 @@ func TestLoad(t *testing.T) {
 	})
 
 	t.Run("LoadURL", func(t *testing.T) {
-		svr := http.Server{Addr: ":8888", Handler: http.FileServer(http.Dir("."))}
+		// listen before starting the server goroutine, so the tests don't race the server startup
+		l, err := net.Listen("tcp", "localhost:8888")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		svr := http.Server{Handler: http.FileServer(http.Dir("."))}
 		go func() {
-			svr.ListenAndServe()
+			svr.Serve(l)
 		}()
 		defer svr.Shutdown(context.Background())
 
@@ -104,6 +116,10 @@ func TestLoad(t *testing.T) {
 			}
 			defer c.Close()
 
+			if c.Writable() {
+				t.Error("http source is writable")
+			}
+
 			if len(c.Sections()) < 1 {
 				t.Error("missing section data")
 			}
@@ -148,6 +164,10 @@ func TestLoad(t *testing.T) {
 			}
 			defer c.Close()
 
+			if c.Writable() {
+				t.Error("bytes source is writable")
+			}
+
 			if len(c.Sections()) < 1 {
 				t.Error("missing section data")
 			}
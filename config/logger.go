@@ -0,0 +1,34 @@
+package config
+
+// Logger is the interface for the optional logging of events in the providers and resolver.  The method set matches
+// the leveled methods of the log/slog Logger type, so a *slog.Logger can be used directly, and args are treated as
+// alternating key/value pairs.  Only debug level events are currently emitted.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// nopLogger is the default Logger, which discards all events
+type nopLogger struct{}
+
+func (l nopLogger) Debug(string, ...interface{}) {}
+func (l nopLogger) Info(string, ...interface{})  {}
+func (l nopLogger) Warn(string, ...interface{})  {}
+func (l nopLogger) Error(string, ...interface{}) {}
+
+// WithLogger is an Option for setting the Logger used by a provider or resolver.  If not set, events are discarded.
+func WithLogger(l Logger) Option {
+	return func(o *options) {
+		o.log = l
+	}
+}
+
+// loggerOrNop returns the provided Logger, or the nopLogger if l is nil
+func loggerOrNop(l Logger) Logger {
+	if l == nil {
+		return nopLogger{}
+	}
+	return l
+}
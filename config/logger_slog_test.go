@@ -0,0 +1,32 @@
+//go:build go1.21
+// +build go1.21
+
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestWithLogger_Slog(t *testing.T) {
+	b := new(bytes.Buffer)
+	l := slog.New(slog.NewTextHandler(b, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	p, err := NewIniConfigProvider(ConfFileName, WithLogger(l))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer p.Close()
+
+	if _, err := p.Config("other"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !strings.Contains(b.String(), `msg="profile lookup" profile=other path=.aws_config`) {
+		t.Errorf("unexpected output: %s", b.String())
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testLogger struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *testLogger) log(level, msg string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, fmt.Sprintf("%s %s %v", level, msg, args))
+}
+
+func (l *testLogger) Debug(msg string, args ...interface{}) { l.log("DEBUG", msg, args...) }
+func (l *testLogger) Info(msg string, args ...interface{})  { l.log("INFO", msg, args...) }
+func (l *testLogger) Warn(msg string, args ...interface{})  { l.log("WARN", msg, args...) }
+func (l *testLogger) Error(msg string, args ...interface{}) { l.log("ERROR", msg, args...) }
+
+// has returns true if an event starting with msg was logged
+func (l *testLogger) has(msg string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, e := range l.events {
+		if strings.HasPrefix(e, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Run("ini config", func(t *testing.T) {
+		l := new(testLogger)
+		p, err := NewIniConfigProvider(ConfFileName, WithLogger(l))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer p.Close()
+
+		if _, err := p.Config("other"); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !l.has("DEBUG loaded ini data [path .aws_config bytes ") {
+			t.Errorf("missing load event: %v", l.events)
+		}
+
+		if !l.has("DEBUG profile not found, trying alternate section name [profile other section profile other]") {
+			t.Errorf("missing fallback event: %v", l.events)
+		}
+	})
+
+	t.Run("default file", func(t *testing.T) {
+		l := new(testLogger)
+		env := MapEnvironment{CredentialsFileEnvVar: "not_my_file"}
+		if _, err := NewIniCredentialProvider(nil, WithEnvironment(env), WithLogger(l)); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !l.has("DEBUG default file not found, using empty default profile [path not_my_file]") {
+			t.Errorf("missing default file event: %v", l.events)
+		}
+	})
+
+	t.Run("resolver", func(t *testing.T) {
+		l := new(testLogger)
+		r, err := NewAwsConfigResolver(ConfFileName, WithLogger(l))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if _, err := r.Resolve("mfa"); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !l.has("DEBUG resolving profile [profile mfa]") || !l.has("DEBUG merging source_profile [profile mfa source_profile default]") {
+			t.Errorf("missing resolver events: %v", l.events)
+		}
+	})
+
+	t.Run("env", func(t *testing.T) {
+		l := new(testLogger)
+		env := MapEnvironment{"AWS_REGION": "us-east-1", "AWS_ACCESS_KEY_ID": "mykey"}
+
+		if _, err := NewEnvConfigProvider(WithEnvironment(env), WithLogger(l)).Config(); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if _, err := NewEnvCredentialProvider(WithEnvironment(env), WithLogger(l)).Credentials(); err == nil {
+			t.Error("did not receive expected error")
+			return
+		}
+
+		if !l.has("DEBUG attribute found in environment [attribute region]") || !l.has("DEBUG secret key not found in environment []") {
+			t.Errorf("missing env events: %v", l.events)
+		}
+	})
+
+	t.Run("nil logger", func(t *testing.T) {
+		p, err := NewIniConfigProvider(ConfFileName, WithLogger(nil))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer p.Close()
+
+		if _, err := p.Config("other"); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,304 @@
+package config
+
+import (
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// MemorySource is the Source value of profile entries returned by the in-memory providers
+const MemorySource = "memory"
+
+// MemoryConfigProvider enables the lookup of AWS configuration from an in-memory set of profiles.  It is
+// primarily intended as a test double for code using an AwsConfigProvider.  It is safe for concurrent use.
+type MemoryConfigProvider struct {
+	profiles map[string]map[string]string
+	policy   *ProfilePolicy
+	mu       sync.RWMutex
+}
+
+// NewMemoryConfigProvider creates a MemoryConfigProvider with no profiles
+func NewMemoryConfigProvider(opts ...Option) *MemoryConfigProvider {
+	return &MemoryConfigProvider{profiles: make(map[string]map[string]string), policy: newOptions(opts).policy()}
+}
+
+// WithProfile is a fluent method for adding a profile with the given attributes to the provider, replacing any
+// existing profile of the same name
+func (p *MemoryConfigProvider) WithProfile(name string, attrs map[string]string) *MemoryConfigProvider {
+	m := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		m[k] = v
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.profiles[name] = m
+	return p
+}
+
+// WithProfilePolicy is a fluent method for setting the ProfilePolicy used to select the profile when a profile
+// name is not provided
+func (p *MemoryConfigProvider) WithProfilePolicy(policy *ProfilePolicy) *MemoryConfigProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.policy = policy
+	return p
+}
+
+// Config will return the configuration attributes for the specified profile.  If the profile is nil or empty, the
+// profile is selected using the provider's ProfilePolicy.
+func (p *MemoryConfigProvider) Config(profile ...string) (*AwsConfig, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	name := policyOrDefault(p.policy).Resolve(firstOrEmpty(profile))
+
+	attrs, ok := p.profiles[name]
+	if !ok {
+		return nil, &ProfileNotFoundError{Profile: name}
+	}
+
+	c := &AwsConfig{Profile: name, rawAttributes: make(map[string]string, len(attrs))}
+	for k, v := range attrs {
+		c.rawAttributes[k] = v
+	}
+	c.setFields()
+
+	return c, nil
+}
+
+// ListProfiles will return an array of the profile names in the provider.  If the roles arg is true, only profiles
+// which have the role_arn attribute will be returned.
+func (p *MemoryConfigProvider) ListProfiles(roles bool) []string {
+	q := new(ProfileQuery)
+	if roles {
+		q.Attributes = map[string]string{"role_arn": ""}
+	}
+
+	profiles := make([]string, 0)
+	for _, e := range p.QueryProfiles(q) {
+		profiles = append(profiles, e.Name)
+	}
+	return profiles
+}
+
+// QueryProfiles will return the metadata for the profiles matching the query, sorted by profile name.
+// The Section and Source of the returned entries are the profile name and "memory".
+func (p *MemoryConfigProvider) QueryProfiles(q *ProfileQuery) []ProfileEntry {
+	entries := make([]ProfileEntry, 0)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for n, attrs := range p.profiles {
+		if q.Matches(n, attrs) {
+			entries = append(entries, ProfileEntry{Name: n, Section: n, Kind: profileKind(attrs), Source: MemorySource})
+		}
+	}
+
+	sortProfileEntries(entries)
+	return entries
+}
+
+// MemoryCredentialProvider enables the lookup of AWS credentials from an in-memory set of profiles.  It is
+// primarily intended as a test double for code using an AwsCredentialProvider.  It is safe for concurrent use.
+type MemoryCredentialProvider struct {
+	creds  map[string]credentials.Value
+	policy *ProfilePolicy
+	mu     sync.RWMutex
+}
+
+// NewMemoryCredentialProvider creates a MemoryCredentialProvider with no credentials
+func NewMemoryCredentialProvider(opts ...Option) *MemoryCredentialProvider {
+	return &MemoryCredentialProvider{creds: make(map[string]credentials.Value), policy: newOptions(opts).policy()}
+}
+
+// WithCredentials is a fluent method for setting the credentials of a profile
+func (p *MemoryCredentialProvider) WithCredentials(profile string, creds credentials.Value) *MemoryCredentialProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.creds[profile] = creds
+	return p
+}
+
+// WithProfilePolicy is a fluent method for setting the ProfilePolicy used to select the profile when a profile
+// name is not provided
+func (p *MemoryCredentialProvider) WithProfilePolicy(policy *ProfilePolicy) *MemoryCredentialProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.policy = policy
+	return p
+}
+
+// Credentials will return the credentials for the specified profile.  If the profile is nil or empty, the profile is
+// selected using the provider's ProfilePolicy.
+func (p *MemoryCredentialProvider) Credentials(profile ...string) (credentials.Value, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	name := policyOrDefault(p.policy).Resolve(firstOrEmpty(profile))
+
+	v, ok := p.creds[name]
+	if !ok {
+		return v, &ProfileNotFoundError{Profile: name}
+	}
+
+	if !v.HasKeys() {
+		return v, &IncompleteCredentialsError{Profile: name}
+	}
+	return v, nil
+}
+
+// UpdateCredentials updates the given profile with the provided credentials, using the same rules as
+// IniCredentialProvider.UpdateCredentials().  Unlike the IniCredentialProvider, the profile does not need to exist.
+func (p *MemoryCredentialProvider) UpdateCredentials(profile string, creds interface{}) error {
+	c, err := newAwsCredentials(creds)
+	if err != nil || c == nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.creds[profile] = credentials.Value{
+		AccessKeyID:     c.AccessKey,
+		SecretAccessKey: c.SecretKey,
+		SessionToken:    c.SessionToken,
+		ProviderName:    MemorySource,
+	}
+	return nil
+}
+
+// ListProfiles returns the sorted names of the profiles with credentials in the provider
+func (p *MemoryCredentialProvider) ListProfiles() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	profiles := make([]string, 0, len(p.creds))
+	for k := range p.creds {
+		profiles = append(profiles, k)
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// ProfileBuilder is a fluent builder for declaring the profiles of a MemoryConfigProvider and MemoryCredentialProvider.
+// Attribute and credential methods apply to the current profile, which is set using Profile().  Until Profile() is
+// called, the current profile is the default profile.
+//
+//	b := NewProfileBuilder().
+//		Profile("default").Region("us-east-1").Credentials("AKIAMOCK", "secret").
+//		Profile("admin").RoleArn("arn:aws:iam::123456789012:role/Admin").SourceProfile("default")
+//	c, err := b.ConfigProvider().Config("admin")
+type ProfileBuilder struct {
+	config *MemoryConfigProvider
+	creds  *MemoryCredentialProvider
+	name   string
+}
+
+// NewProfileBuilder creates a ProfileBuilder with an empty default profile
+func NewProfileBuilder() *ProfileBuilder {
+	b := &ProfileBuilder{config: NewMemoryConfigProvider(), creds: NewMemoryCredentialProvider()}
+	return b.Profile(DefaultProfileName)
+}
+
+// Profile sets the current profile, creating it if it doesn't exist
+func (b *ProfileBuilder) Profile(name string) *ProfileBuilder {
+	b.name = name
+
+	b.config.mu.Lock()
+	defer b.config.mu.Unlock()
+
+	if _, ok := b.config.profiles[name]; !ok {
+		b.config.profiles[name] = make(map[string]string)
+	}
+	return b
+}
+
+// Set sets the value of an attribute in the current profile
+func (b *ProfileBuilder) Set(attr, value string) *ProfileBuilder {
+	b.config.mu.Lock()
+	defer b.config.mu.Unlock()
+
+	b.config.profiles[b.name][attr] = value
+	return b
+}
+
+// Region sets the region attribute of the current profile
+func (b *ProfileBuilder) Region(v string) *ProfileBuilder {
+	return b.Set("region", v)
+}
+
+// RoleArn sets the role_arn attribute of the current profile
+func (b *ProfileBuilder) RoleArn(v string) *ProfileBuilder {
+	return b.Set("role_arn", v)
+}
+
+// SourceProfile sets the source_profile attribute of the current profile
+func (b *ProfileBuilder) SourceProfile(v string) *ProfileBuilder {
+	return b.Set("source_profile", v)
+}
+
+// CredentialSource sets the credential_source attribute of the current profile
+func (b *ProfileBuilder) CredentialSource(v string) *ProfileBuilder {
+	return b.Set("credential_source", v)
+}
+
+// MfaSerial sets the mfa_serial attribute of the current profile
+func (b *ProfileBuilder) MfaSerial(v string) *ProfileBuilder {
+	return b.Set("mfa_serial", v)
+}
+
+// ExternalId sets the external_id attribute of the current profile
+func (b *ProfileBuilder) ExternalId(v string) *ProfileBuilder {
+	return b.Set("external_id", v)
+}
+
+// RoleSessionName sets the role_session_name attribute of the current profile
+func (b *ProfileBuilder) RoleSessionName(v string) *ProfileBuilder {
+	return b.Set("role_session_name", v)
+}
+
+// DurationSeconds sets the duration_seconds attribute of the current profile
+func (b *ProfileBuilder) DurationSeconds(v int) *ProfileBuilder {
+	return b.Set("duration_seconds", strconv.Itoa(v))
+}
+
+// Credentials sets the access key and secret key of the current profile in the credential provider
+func (b *ProfileBuilder) Credentials(accessKey, secretKey string) *ProfileBuilder {
+	b.creds.mu.Lock()
+	defer b.creds.mu.Unlock()
+
+	v := b.creds.creds[b.name]
+	v.AccessKeyID = accessKey
+	v.SecretAccessKey = secretKey
+	v.ProviderName = MemorySource
+	b.creds.creds[b.name] = v
+	return b
+}
+
+// SessionToken sets the session token of the current profile in the credential provider
+func (b *ProfileBuilder) SessionToken(token string) *ProfileBuilder {
+	b.creds.mu.Lock()
+	defer b.creds.mu.Unlock()
+
+	v := b.creds.creds[b.name]
+	v.SessionToken = token
+	v.ProviderName = MemorySource
+	b.creds.creds[b.name] = v
+	return b
+}
+
+// ConfigProvider returns the MemoryConfigProvider populated by the builder
+func (b *ProfileBuilder) ConfigProvider() *MemoryConfigProvider {
+	return b.config
+}
+
+// CredentialProvider returns the MemoryCredentialProvider populated by the builder
+func (b *ProfileBuilder) CredentialProvider() *MemoryCredentialProvider {
+	return b.creds
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"testing"
+)
+
+func TestMemoryConfigProvider(t *testing.T) {
+	p := NewMemoryConfigProvider().
+		WithProfile(DefaultProfileName, map[string]string{"region": "us-east-1"}).
+		WithProfile("admin", map[string]string{"role_arn": "arn:aws:iam::123456789012:role/Admin", "duration_seconds": "3600"})
+
+	t.Run("default", func(t *testing.T) {
+		c, err := p.Config()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Profile != DefaultProfileName || c.Region != "us-east-1" {
+			t.Error("data mismatch")
+		}
+	})
+
+	t.Run("named", func(t *testing.T) {
+		c, err := p.Config("admin")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Profile != "admin" || len(c.RoleArn) < 1 || c.DurationSeconds != 3600 {
+			t.Error("data mismatch")
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if _, err := p.Config("nope"); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		if l := p.ListProfiles(false); len(l) != 2 || l[0] != "admin" {
+			t.Errorf("unexpected profiles: %v", l)
+		}
+
+		if l := p.ListProfiles(true); len(l) != 1 {
+			t.Errorf("unexpected profiles: %v", l)
+		}
+	})
+
+	t.Run("query", func(t *testing.T) {
+		e := p.QueryProfiles(&ProfileQuery{Kind: ProfileKindRole})
+		if len(e) != 1 || e[0].Source != MemorySource {
+			t.Errorf("unexpected entries: %v", e)
+		}
+	})
+
+	t.Run("resolver", func(t *testing.T) {
+		r := new(awsConfigResolver).WithConfigProvider(p).WithLookupDefaultProfile(true)
+
+		c, err := r.Resolve("admin")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Region != "us-east-1" || c.Source("region") != DefaultProfileName {
+			t.Error("data mismatch")
+		}
+	})
+}
+
+func TestMemoryCredentialProvider(t *testing.T) {
+	p := NewMemoryCredentialProvider().
+		WithCredentials(DefaultProfileName, credentials.Value{AccessKeyID: "ak", SecretAccessKey: "sk"}).
+		WithCredentials("partial", credentials.Value{AccessKeyID: "ak"})
+
+	t.Run("default", func(t *testing.T) {
+		v, err := p.Credentials()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if v.AccessKeyID != "ak" {
+			t.Error("data mismatch")
+		}
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		if _, err := p.Credentials("partial"); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if _, err := p.Credentials("nope"); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		if err := p.UpdateCredentials("new", credentials.Value{AccessKeyID: "nak", SecretAccessKey: "nsk"}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		v, err := p.Credentials("new")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if v.AccessKeyID != "nak" || len(p.ListProfiles()) != 3 {
+			t.Error("data mismatch")
+		}
+	})
+
+	t.Run("update bad type", func(t *testing.T) {
+		if err := p.UpdateCredentials("new", "creds"); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestProfileBuilder(t *testing.T) {
+	b := NewProfileBuilder().
+		Region("us-east-2").Credentials("ak", "sk").
+		Profile("admin").RoleArn("arn:aws:iam::123456789012:role/Admin").SourceProfile(DefaultProfileName).
+		MfaSerial("GAHT12345678").ExternalId("ext").RoleSessionName("me").DurationSeconds(7200).Set("custom", "x").
+		Profile("session").CredentialSource("Environment").Credentials("tak", "tsk").SessionToken("tok")
+
+	c, err := b.ConfigProvider().Config("admin")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if c.SourceProfile != DefaultProfileName || c.MfaSerial != "GAHT12345678" || c.ExternalId != "ext" ||
+		c.RoleSessionName != "me" || c.DurationSeconds != 7200 || c.Get("custom") != "x" {
+		t.Errorf("data mismatch: %+v", c)
+	}
+
+	if c, err := b.ConfigProvider().Config(); err != nil || c.Region != "us-east-2" {
+		t.Error("bad default profile")
+	}
+
+	v, err := b.CredentialProvider().Credentials("session")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if v.AccessKeyID != "tak" || v.SessionToken != "tok" {
+		t.Error("credential mismatch")
+	}
+}
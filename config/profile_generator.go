@@ -0,0 +1,409 @@
+package config
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Account is an AWS account to generate a profile for.  The json field names match the AWS Organizations
+// ListAccounts API output, so the output of 'aws organizations list-accounts' can be used as the account list.
+type Account struct {
+	Id     string `json:"Id" yaml:"id"`
+	Name   string `json:"Name" yaml:"name"`
+	Status string `json:"Status,omitempty" yaml:"status,omitempty"`
+}
+
+// OrganizationsClient is the subset of the AWS SDK Organizations API used to list the accounts in an organization.
+// The *organizations.Organizations type (and the organizationsiface.OrganizationsAPI interface) satisfy this interface,
+// allowing a fake implementation to be used for testing.
+type OrganizationsClient interface {
+	ListAccountsPages(input *organizations.ListAccountsInput, fn func(*organizations.ListAccountsOutput, bool) bool) error
+}
+
+var accountIdPattern = regexp.MustCompile(`^\d{12}$`)
+
+// OrganizationsAccounts returns the active accounts in the organization
+func OrganizationsAccounts(client OrganizationsClient) ([]Account, error) {
+	accounts := make([]Account, 0)
+
+	err := client.ListAccountsPages(new(organizations.ListAccountsInput), func(o *organizations.ListAccountsOutput, last bool) bool {
+		for _, a := range o.Accounts {
+			accounts = append(accounts, Account{Id: aws.StringValue(a.Id), Name: aws.StringValue(a.Name),
+				Status: aws.StringValue(a.Status)})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return activeAccounts(accounts)
+}
+
+// ReadAccountsJson returns the active accounts from the JSON data, which is either an array of accounts, or an object
+// with an Accounts attribute holding the array (the format of the 'aws organizations list-accounts' output)
+func ReadAccountsJson(r io.Reader) ([]Account, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]Account, 0)
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '{' {
+		o := struct{ Accounts []Account }{}
+		if err := json.Unmarshal(data, &o); err != nil {
+			return nil, err
+		}
+		accounts = o.Accounts
+	} else if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, err
+	}
+
+	return activeAccounts(accounts)
+}
+
+// ReadAccountsCsv returns the active accounts from the CSV data.  If the first record is a header (the first field
+// isn't an account id), the id, name and status columns are found by name (case-insensitive, "account_id" and
+// "account_name" are also recognized), otherwise the first field is the account id, and the second is the name.
+func ReadAccountsCsv(r io.Reader) ([]Account, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	cols := map[string]int{"id": 0, "name": 1, "status": -1}
+	if len(records) > 0 && len(records[0]) > 0 && !accountIdPattern.MatchString(records[0][0]) {
+		cols = map[string]int{"id": -1, "name": -1, "status": -1}
+		for i, h := range records[0] {
+			h = strings.TrimPrefix(strings.Replace(strings.ToLower(strings.TrimSpace(h)), " ", "_", -1), "account_")
+			if _, ok := cols[h]; ok {
+				cols[h] = i
+			}
+		}
+
+		if cols["id"] < 0 {
+			return nil, fmt.Errorf("account id column not found in csv header")
+		}
+		records = records[1:]
+	}
+
+	field := func(rec []string, col string) string {
+		if i := cols[col]; i >= 0 && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	accounts := make([]Account, 0, len(records))
+	for _, rec := range records {
+		accounts = append(accounts, Account{Id: field(rec, "id"), Name: field(rec, "name"), Status: field(rec, "status")})
+	}
+
+	return activeAccounts(accounts)
+}
+
+// activeAccounts returns the accounts without a status, or with the ACTIVE status, and checks the account ids are valid
+func activeAccounts(accounts []Account) ([]Account, error) {
+	active := make([]Account, 0, len(accounts))
+	for _, a := range accounts {
+		if len(a.Status) > 0 && !strings.EqualFold(a.Status, organizations.AccountStatusActive) {
+			continue
+		}
+
+		if !accountIdPattern.MatchString(a.Id) {
+			return nil, fmt.Errorf("invalid account id '%s' for account '%s'", a.Id, a.Name)
+		}
+		active = append(active, a)
+	}
+	return active, nil
+}
+
+// ChangeAction is the change made to a profile by the ProfileGenerator
+type ChangeAction string
+
+const (
+	// ProfileAdded is a new profile
+	ProfileAdded ChangeAction = "added"
+	// ProfileUpdated is an existing profile with changed attribute values
+	ProfileUpdated ChangeAction = "updated"
+	// ProfileUnchanged is an existing profile already having the generated attribute values
+	ProfileUnchanged ChangeAction = "unchanged"
+)
+
+// AttributeChange is the old and new value of a generated profile attribute.  Old is empty for new attributes.
+type AttributeChange struct {
+	Name string `json:"name" yaml:"name"`
+	Old  string `json:"old,omitempty" yaml:"old,omitempty"`
+	New  string `json:"new" yaml:"new"`
+}
+
+// ProfileChange is the change to the profile generated for an account.  Attributes only holds the changed attributes,
+// so it is empty for unchanged profiles.
+type ProfileChange struct {
+	Profile    string            `json:"profile" yaml:"profile"`
+	AccountId  string            `json:"account_id" yaml:"account_id"`
+	Action     ChangeAction      `json:"action" yaml:"action"`
+	Attributes []AttributeChange `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+	section    string
+}
+
+// Diff returns the change formatted as a diff of the profile section, with removed lines prefixed by '-', and added
+// lines prefixed by '+'.  An empty string is returned for unchanged profiles.
+func (c ProfileChange) Diff() string {
+	if c.Action == ProfileUnchanged {
+		return ""
+	}
+
+	var b strings.Builder
+	if c.Action == ProfileAdded {
+		b.WriteString(fmt.Sprintf("+[%s]\n", c.section))
+	} else {
+		b.WriteString(fmt.Sprintf(" [%s]\n", c.section))
+	}
+
+	for _, a := range c.Attributes {
+		if len(a.Old) > 0 {
+			b.WriteString(fmt.Sprintf("-%s = %s\n", a.Name, a.Old))
+		}
+		b.WriteString(fmt.Sprintf("+%s = %s\n", a.Name, a.New))
+	}
+
+	return b.String()
+}
+
+// ProfileGenerator creates, or updates, a role profile in the config file for each account in a list of accounts.
+// The profiles are based on a template profile (typically holding the source_profile and region attributes), and
+// assume the named role in the account.  Existing profiles are matched by the generated profile name, and only the
+// generated attributes are updated, so running the generator again for the same accounts makes no changes, and any
+// attributes added to a generated profile are kept.
+type ProfileGenerator struct {
+	RoleName   string
+	Template   string
+	nameFormat string
+	inherit    bool
+}
+
+// NewProfileGenerator creates a ProfileGenerator for the role name and template profile.  If the role name is empty,
+// the role_arn attribute of the template is used, which is expected to reference the ${account_id} variable.  Role
+// ARNs for the role name use the partition of the template role_arn or region (for example, aws-cn for cn-north-1).
+func NewProfileGenerator(roleName, template string) *ProfileGenerator {
+	return &ProfileGenerator{RoleName: roleName, Template: template, nameFormat: "${account_name}"}
+}
+
+// WithNameFormat is a fluent method for setting the format of the generated profile names, which may reference the
+// ${account_id}, ${account_name} and ${role_name} variables.  The default format is ${account_name}.  Generated names
+// are lower case, with runs of characters other than letters, numbers, '.', '_' and '-' replaced by a single '-'.
+func (g *ProfileGenerator) WithNameFormat(format string) *ProfileGenerator {
+	g.nameFormat = format
+	return g
+}
+
+// WithInherit is a fluent method for generating profiles which inherit the template profile using the x_inherit
+// attribute, so later changes to the template apply to all generated profiles.  The account_id and account_name
+// attributes are set in the generated profiles, for use as variables in the template attributes.  By default, the resolved template
+// attributes are copied to each generated profile, with variable references interpolated using the account details.
+func (g *ProfileGenerator) WithInherit(inherit bool) *ProfileGenerator {
+	g.inherit = inherit
+	return g
+}
+
+// Plan returns the changes needed to generate the profiles for the accounts, in the order of the account list, without
+// updating the config file.  It is an error if the template profile is not found, or if two accounts generate the
+// same profile name.
+func (g *ProfileGenerator) Plan(p *IniConfigProvider, accounts []Account) ([]ProfileChange, error) {
+	tmpl, err := g.template(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(g.RoleName) < 1 && len(tmpl["role_arn"]) < 1 {
+		return nil, fmt.Errorf("a role name is required if the template profile has no role_arn")
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	seen := make(map[string]string)
+	changes := make([]ProfileChange, 0, len(accounts))
+
+	for _, a := range accounts {
+		name, err := g.profileName(a)
+		if err != nil {
+			return nil, err
+		}
+
+		if id, ok := seen[name]; ok {
+			return nil, fmt.Errorf("accounts %s and %s both generate profile '%s'", id, a.Id, name)
+		}
+		seen[name] = a.Id
+
+		changes = append(changes, g.change(p, name, a, g.attributes(tmpl, name, a)))
+	}
+
+	return changes, nil
+}
+
+// Apply updates the config file with the planned changes.  Updates are only made to the in-memory representation of
+// the data, it is the caller's responsibility to persist the information to storage.
+func (g *ProfileGenerator) Apply(p *IniConfigProvider, changes []ProfileChange) error {
+	for _, c := range changes {
+		for _, a := range c.Attributes {
+			if err := p.SetAttribute(c.Profile, a.Name, a.New); err != nil {
+				return err
+			}
+		}
+
+		if c.Action != ProfileUnchanged {
+			p.logger().Debug("generated profile", "profile", c.Profile, "account", c.AccountId, "action", string(c.Action))
+		}
+	}
+	return nil
+}
+
+// Generate plans and applies the changes to generate the profiles for the accounts, and returns the changes made
+func (g *ProfileGenerator) Generate(p *IniConfigProvider, accounts []Account) ([]ProfileChange, error) {
+	changes, err := g.Plan(p, accounts)
+	if err != nil {
+		return nil, err
+	}
+	return changes, g.Apply(p, changes)
+}
+
+// template returns the resolved attributes of the template profile, including the attributes it inherits
+func (g *ProfileGenerator) template(p *IniConfigProvider) (map[string]string, error) {
+	attrs := make(map[string]string)
+	if len(g.Template) < 1 {
+		if g.inherit {
+			return nil, fmt.Errorf("a template profile is required to generate inheriting profiles")
+		}
+		return attrs, nil
+	}
+
+	chain, err := inheritChain(p, g.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range chain {
+		for k, v := range c.Attributes() {
+			attrs[k] = v
+		}
+	}
+	return attrs, nil
+}
+
+var profileNameReplacer = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// profileName returns the name of the profile generated for the account
+func (g *ProfileGenerator) profileName(a Account) (string, error) {
+	n := expandVariables(g.nameFormat, map[string]string{"account_id": a.Id, "account_name": a.Name, "role_name": g.RoleName})
+	n = strings.Trim(profileNameReplacer.ReplaceAllString(strings.ToLower(n), "-"), "-")
+
+	if len(n) < 1 {
+		return "", fmt.Errorf("empty profile name generated for account %s", a.Id)
+	}
+	return n, nil
+}
+
+// attributes returns the attributes of the profile generated for the account, in the order they are written
+func (g *ProfileGenerator) attributes(tmpl map[string]string, name string, a Account) []AttributeChange {
+	attrs := make([]AttributeChange, 0)
+	roleArn := ""
+	if len(g.RoleName) > 0 {
+		roleArn = fmt.Sprintf("arn:%s:iam::%s:role/%s", templatePartition(tmpl), a.Id, g.RoleName)
+	}
+
+	if g.inherit {
+		attrs = append(attrs, AttributeChange{Name: InheritAttribute, New: g.Template})
+		if len(roleArn) > 0 {
+			attrs = append(attrs, AttributeChange{Name: "role_arn", New: roleArn})
+		}
+		attrs = append(attrs, AttributeChange{Name: "account_id", New: a.Id})
+
+		// the account name is only known here, so keep it for templates referencing it
+		if len(a.Name) > 0 {
+			attrs = append(attrs, AttributeChange{Name: "account_name", New: a.Name})
+		}
+		return attrs
+	}
+
+	vars := map[string]string{"account_id": a.Id, "account_name": a.Name, "role_name": g.RoleName, "profile": name}
+	if len(roleArn) < 1 {
+		roleArn = expandVariables(tmpl["role_arn"], vars)
+	}
+	attrs = append(attrs, AttributeChange{Name: "role_arn", New: roleArn})
+
+	keys := make([]string, 0, len(tmpl))
+	for k := range tmpl {
+		switch k {
+		case InheritAttribute, "role_arn", "account_id", "account_name":
+			continue
+		}
+
+		if !stringInSlice(k, credentialAttributes) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		attrs = append(attrs, AttributeChange{Name: k, New: expandVariables(tmpl[k], vars)})
+	}
+	return append(attrs, AttributeChange{Name: "account_id", New: a.Id})
+}
+
+// change compares the generated attributes with the existing profile.  The caller is expected to hold the mutex.
+func (g *ProfileGenerator) change(p *IniConfigProvider, name string, a Account, attrs []AttributeChange) ProfileChange {
+	c := ProfileChange{Profile: name, AccountId: a.Id, Action: ProfileAdded, Attributes: attrs, section: "profile " + name}
+	if name == DefaultProfileName {
+		c.section = name
+	}
+
+	s, err := p.configProfile(name)
+	if err != nil {
+		return c
+	}
+
+	c.section = s.Name()
+	c.Action = ProfileUnchanged
+	c.Attributes = make([]AttributeChange, 0)
+
+	cur := s.KeysHash()
+	for _, x := range attrs {
+		if v, ok := cur[x.Name]; !ok || v != x.New {
+			x.Old = v
+			c.Attributes = append(c.Attributes, x)
+			c.Action = ProfileUpdated
+		}
+	}
+
+	return c
+}
+
+// templatePartition returns the partition of the role ARNs generated using the template attributes, which is the
+// partition of the template role_arn, or of the template region, falling back to the aws partition
+func templatePartition(tmpl map[string]string) string {
+	if a, err := arn.Parse(tmpl["role_arn"]); err == nil {
+		return a.Partition
+	}
+
+	if p, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), tmpl["region"]); ok {
+		return p.ID()
+	}
+	return endpoints.AwsPartitionID
+}
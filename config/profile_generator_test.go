@@ -0,0 +1,386 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"strings"
+	"testing"
+)
+
+// fakeOrganizations is an OrganizationsClient returning each slice of accounts as a page of results
+type fakeOrganizations struct {
+	pages [][]*organizations.Account
+	err   error
+}
+
+func (f *fakeOrganizations) ListAccountsPages(input *organizations.ListAccountsInput, fn func(*organizations.ListAccountsOutput, bool) bool) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	for i, p := range f.pages {
+		if !fn(&organizations.ListAccountsOutput{Accounts: p}, i == len(f.pages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func orgAccount(id, name, status string) *organizations.Account {
+	return &organizations.Account{Id: aws.String(id), Name: aws.String(name), Status: aws.String(status)}
+}
+
+var generatorConfig = []byte(`[default]
+region = us-east-1
+
+[profile base]
+source_profile = default
+
+# accounts
+[profile account-template]
+x_inherit         = base
+region            = us-west-2
+role_session_name = ${account_name}-session
+
+[profile prod-account]
+role_arn = arn:aws:iam::111111111111:role/Admin
+account_id = 111111111111
+mfa_serial = arn:aws:iam::123456789012:mfa/me
+`)
+
+var generatorAccounts = []Account{{Id: "111111111111", Name: "Prod Account"}, {Id: "222222222222", Name: "Dev"}}
+
+func TestOrganizationsAccounts(t *testing.T) {
+	t.Run("good", func(t *testing.T) {
+		f := &fakeOrganizations{pages: [][]*organizations.Account{
+			{orgAccount("111111111111", "Prod Account", organizations.AccountStatusActive)},
+			{orgAccount("222222222222", "Dev", organizations.AccountStatusActive),
+				orgAccount("333333333333", "Gone", organizations.AccountStatusSuspended)},
+		}}
+
+		a, err := OrganizationsAccounts(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fmt.Sprint(a) != fmt.Sprint([]Account{{"111111111111", "Prod Account", "ACTIVE"}, {"222222222222", "Dev", "ACTIVE"}}) {
+			t.Errorf("unexpected accounts: %v", a)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		if _, err := OrganizationsAccounts(&fakeOrganizations{err: fmt.Errorf("denied")}); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestReadAccountsJson(t *testing.T) {
+	t.Run("list-accounts output", func(t *testing.T) {
+		data := `{"Accounts": [{"Id": "111111111111", "Name": "Prod", "Status": "ACTIVE"},
+			{"Id": "333333333333", "Name": "Gone", "Status": "SUSPENDED"}]}`
+
+		a, err := ReadAccountsJson(strings.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(a) != 1 || a[0].Id != "111111111111" || a[0].Name != "Prod" {
+			t.Errorf("unexpected accounts: %v", a)
+		}
+	})
+
+	t.Run("array", func(t *testing.T) {
+		a, err := ReadAccountsJson(strings.NewReader(`[{"id": "111111111111", "name": "Prod"}]`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(a) != 1 || a[0].Id != "111111111111" || a[0].Name != "Prod" {
+			t.Errorf("unexpected accounts: %v", a)
+		}
+	})
+
+	t.Run("bad id", func(t *testing.T) {
+		if _, err := ReadAccountsJson(strings.NewReader(`[{"id": "1234", "name": "Prod"}]`)); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("bad json", func(t *testing.T) {
+		if _, err := ReadAccountsJson(strings.NewReader(`{"Accounts": `)); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestReadAccountsCsv(t *testing.T) {
+	t.Run("header", func(t *testing.T) {
+		data := "Status,Account Name,Account ID\nACTIVE,Prod,111111111111\nSUSPENDED,Gone,333333333333\n,Dev,222222222222\n"
+
+		a, err := ReadAccountsCsv(strings.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fmt.Sprint(a) != fmt.Sprint([]Account{{"111111111111", "Prod", "ACTIVE"}, {"222222222222", "Dev", ""}}) {
+			t.Errorf("unexpected accounts: %v", a)
+		}
+	})
+
+	t.Run("no header", func(t *testing.T) {
+		a, err := ReadAccountsCsv(strings.NewReader("111111111111, Prod\n222222222222\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fmt.Sprint(a) != fmt.Sprint([]Account{{"111111111111", "Prod", ""}, {"222222222222", "", ""}}) {
+			t.Errorf("unexpected accounts: %v", a)
+		}
+	})
+
+	t.Run("missing id column", func(t *testing.T) {
+		if _, err := ReadAccountsCsv(strings.NewReader("name,status\nProd,ACTIVE\n")); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("bad id", func(t *testing.T) {
+		if _, err := ReadAccountsCsv(strings.NewReader("id,name\nabc,Prod\n")); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestProfileGenerator_Generate(t *testing.T) {
+	t.Run("flatten", func(t *testing.T) {
+		p, err := NewIniConfigProvider(generatorConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		changes, err := NewProfileGenerator("Admin", "account-template").Generate(p, generatorAccounts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(changes) != 2 || changes[0].Action != ProfileUpdated || changes[1].Action != ProfileAdded {
+			t.Fatalf("unexpected changes: %+v", changes)
+		}
+
+		c, err := p.Config("dev")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.RoleArn != "arn:aws:iam::222222222222:role/Admin" || c.SourceProfile != "default" ||
+			c.Region != "us-west-2" || c.RoleSessionName != "Dev-session" || c.Get("account_id") != "222222222222" {
+			t.Errorf("unexpected profile: %+v", c.Attributes())
+		}
+
+		// attributes not generated are kept
+		c, err = p.Config("prod-account")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.MfaSerial != "arn:aws:iam::123456789012:mfa/me" || c.RoleSessionName != "Prod Account-session" {
+			t.Errorf("unexpected profile: %+v", c.Attributes())
+		}
+
+		// running again makes no changes
+		changes, err = NewProfileGenerator("Admin", "account-template").Generate(p, generatorAccounts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, c := range changes {
+			if c.Action != ProfileUnchanged || len(c.Attributes) > 0 || len(c.Diff()) > 0 {
+				t.Errorf("unexpected change: %+v", c)
+			}
+		}
+	})
+
+	t.Run("inherit", func(t *testing.T) {
+		p, err := NewIniConfigProvider(generatorConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		g := NewProfileGenerator("ReadOnly", "account-template").WithInherit(true).WithNameFormat("${account_name}-${role_name}")
+		if _, err := g.Generate(p, generatorAccounts); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := NewAwsConfigResolver([]byte("[default]"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := r.WithConfigProvider(p).Resolve("dev-readonly")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.RoleArn != "arn:aws:iam::222222222222:role/ReadOnly" || c.SourceProfile != "default" ||
+			c.Region != "us-west-2" || c.RoleSessionName != "Dev-session" || c.Get(InheritAttribute) != "account-template" {
+			t.Errorf("unexpected profile: %+v", c.Attributes())
+		}
+
+		if _, err := p.Config("prod-account"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("template role arn", func(t *testing.T) {
+		p, err := NewIniConfigProvider([]byte("[profile t]\nrole_arn = arn:aws:iam::${account_id}:role/Ops\nsource_profile = default\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewProfileGenerator("", "t").WithNameFormat("${account_id}").Generate(p, generatorAccounts); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := p.Config("111111111111")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.RoleArn != "arn:aws:iam::111111111111:role/Ops" {
+			t.Errorf("unexpected role arn: %s", c.RoleArn)
+		}
+	})
+
+	t.Run("partition", func(t *testing.T) {
+		for region, expected := range map[string]string{"cn-north-1": "aws-cn", "us-gov-west-1": "aws-us-gov", "": "aws"} {
+			p, err := NewIniConfigProvider([]byte("[profile t]\nsource_profile = default\nregion = " + region + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := NewProfileGenerator("Admin", "t").Generate(p, generatorAccounts[1:]); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := p.Config("dev")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.RoleArn != "arn:"+expected+":iam::222222222222:role/Admin" {
+				t.Errorf("unexpected role arn for region '%s': %s", region, c.RoleArn)
+			}
+		}
+
+		p, err := NewIniConfigProvider([]byte("[profile t]\nrole_arn = arn:aws-cn:iam::${account_id}:role/Ops\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		changes, err := NewProfileGenerator("Admin", "t").WithInherit(true).Plan(p, generatorAccounts[1:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if d := changes[0].Diff(); !strings.Contains(d, "+role_arn = arn:aws-cn:iam::222222222222:role/Admin\n") {
+			t.Errorf("unexpected diff:\n%s", d)
+		}
+	})
+
+	t.Run("no role", func(t *testing.T) {
+		p, err := NewIniConfigProvider(generatorConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewProfileGenerator("", "account-template").Generate(p, generatorAccounts); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("missing template", func(t *testing.T) {
+		p, err := NewIniConfigProvider(generatorConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewProfileGenerator("Admin", "nope").Generate(p, generatorAccounts); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		p, err := NewIniConfigProvider(generatorConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewProfileGenerator("Admin", "account-template").WithNameFormat("${role_name}").Generate(p, generatorAccounts)
+		if err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestProfileGenerator_Plan(t *testing.T) {
+	p, err := NewIniConfigProvider(generatorConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := NewProfileGenerator("Admin", "account-template").Plan(p, generatorAccounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// planning doesn't update the file
+	if _, err := p.Config("dev"); err == nil {
+		t.Error("profile created by Plan()")
+	}
+
+	diff := make([]string, 0)
+	for _, c := range changes {
+		diff = append(diff, c.Diff())
+	}
+
+	expected := ` [profile prod-account]
++region = us-west-2
++role_session_name = Prod Account-session
++source_profile = default
+`
+	if diff[0] != expected {
+		t.Errorf("unexpected diff:\n%s", diff[0])
+	}
+
+	if !strings.HasPrefix(diff[1], "+[profile dev]\n+role_arn = arn:aws:iam::222222222222:role/Admin\n") {
+		t.Errorf("unexpected diff:\n%s", diff[1])
+	}
+
+	// the changed value is written in place, and new profiles are appended
+	if err := NewProfileGenerator("Admin", "account-template").Apply(p, changes); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err = NewProfileGenerator("Operator", "account-template").Plan(p, generatorAccounts[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = " [profile prod-account]\n-role_arn = arn:aws:iam::111111111111:role/Admin\n+role_arn = arn:aws:iam::111111111111:role/Operator\n"
+	if d := changes[0].Diff(); d != expected {
+		t.Errorf("unexpected diff:\n%s", d)
+	}
+
+	b := new(bytes.Buffer)
+	if _, err := p.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(b.Bytes(), generatorConfig[:bytes.Index(generatorConfig, []byte("mfa_serial"))]) ||
+		!strings.Contains(b.String(), "[profile dev]") {
+		t.Errorf("unexpected file data:\n%s", b.String())
+	}
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileInventoryEntry describes where the data for a single profile is found across the config and credentials files
+type ProfileInventoryEntry struct {
+	// Name is the profile name, without any "profile " prefix
+	Name string
+	// ConfigSection is the name of the config file section for the profile, empty if not in the config file
+	ConfigSection string
+	// CredentialsSection is the name of the credentials file section for the profile, empty if not in the credentials file
+	CredentialsSection string
+	// StaticKeys is true if the credentials file section contains both an access key and secret key
+	StaticKeys bool
+	// Issues is a list of problems found with how the profile is defined in the files
+	Issues []string
+}
+
+// HasConfig returns true if the profile is found in the config file
+func (e *ProfileInventoryEntry) HasConfig() bool {
+	return len(e.ConfigSection) > 0
+}
+
+// HasCredentials returns true if the profile is found in the credentials file
+func (e *ProfileInventoryEntry) HasCredentials() bool {
+	return len(e.CredentialsSection) > 0
+}
+
+// ProfileInventory is the combined set of profiles found in an AWS config file and credentials file
+type ProfileInventory struct {
+	ConfigProvider     *IniConfigProvider
+	CredentialProvider *IniCredentialProvider
+	profiles           map[string]*ProfileInventoryEntry
+}
+
+// NewProfileInventory loads the config and credential sources (using the same source rules as NewIniConfigProvider and
+// NewIniCredentialProvider), and builds the inventory of profiles found in either of them
+func NewProfileInventory(configSource, credentialSource interface{}, opts ...Option) (*ProfileInventory, error) {
+	cp, err := NewIniConfigProvider(configSource, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	cr, err := NewIniCredentialProvider(credentialSource, opts...)
+	if err != nil {
+		_ = cp.Close()
+		return nil, err
+	}
+
+	return NewProfileInventoryFromProviders(cp, cr), nil
+}
+
+// NewProfileInventoryFromProviders builds the inventory of profiles from already initialized providers
+func NewProfileInventoryFromProviders(cp *IniConfigProvider, cr *IniCredentialProvider) *ProfileInventory {
+	i := &ProfileInventory{ConfigProvider: cp, CredentialProvider: cr}
+	i.build()
+	return i
+}
+
+// Profiles returns the inventory entries for all profiles, sorted by profile name
+func (i *ProfileInventory) Profiles() []*ProfileInventoryEntry {
+	e := make([]*ProfileInventoryEntry, 0, len(i.profiles))
+	for _, v := range i.profiles {
+		e = append(e, v)
+	}
+
+	sort.Slice(e, func(x, y int) bool {
+		return e[x].Name < e[y].Name
+	})
+	return e
+}
+
+// Profile returns the inventory entry for the named profile, and a boolean indicating if the profile was found
+func (i *ProfileInventory) Profile(name string) (*ProfileInventoryEntry, bool) {
+	e, ok := i.profiles[name]
+	return e, ok
+}
+
+// Close will close the underlying config and credential providers
+func (i *ProfileInventory) Close() error {
+	var err error
+	if i.ConfigProvider != nil {
+		err = i.ConfigProvider.Close()
+	}
+
+	if i.CredentialProvider != nil {
+		if e := i.CredentialProvider.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (i *ProfileInventory) build() {
+	i.profiles = make(map[string]*ProfileInventoryEntry)
+
+	if i.ConfigProvider != nil {
+		i.ConfigProvider.mu.RLock()
+		defer i.ConfigProvider.mu.RUnlock()
+
+		for _, s := range i.ConfigProvider.Sections() {
+			if !i.ConfigProvider.isProfileSection(s.Name()) {
+				continue
+			}
+
+			e := i.entry(strings.TrimPrefix(s.Name(), "profile "))
+			if s.Name() != DefaultProfileName && !strings.HasPrefix(s.Name(), "profile ") {
+				e.Issues = append(e.Issues,
+					fmt.Sprintf("config file section [%s] should use the 'profile' prefix", s.Name()))
+			}
+
+			if e.HasConfig() {
+				e.Issues = append(e.Issues,
+					fmt.Sprintf("profile defined in config file as both [%s] and [%s]", e.ConfigSection, s.Name()))
+			}
+			e.ConfigSection = s.Name()
+		}
+	}
+
+	if i.CredentialProvider != nil {
+		i.CredentialProvider.mu.RLock()
+		defer i.CredentialProvider.mu.RUnlock()
+
+		for _, s := range i.CredentialProvider.Sections() {
+			if !i.CredentialProvider.isProfileSection(s.Name()) {
+				continue
+			}
+
+			n := s.Name()
+			e := i.entry(strings.TrimPrefix(n, "profile "))
+			if strings.HasPrefix(n, "profile ") {
+				e.Issues = append(e.Issues,
+					fmt.Sprintf("credentials file section [%s] should not use the 'profile' prefix", n))
+			}
+
+			if e.HasCredentials() {
+				e.Issues = append(e.Issues,
+					fmt.Sprintf("profile defined in credentials file as both [%s] and [%s]", e.CredentialsSection, n))
+			}
+			e.CredentialsSection = n
+			attrs := s.KeysHash()
+			e.StaticKeys = len(attrs["aws_access_key_id"]) > 0 && len(attrs["aws_secret_access_key"]) > 0
+		}
+	}
+}
+
+func (i *ProfileInventory) entry(name string) *ProfileInventoryEntry {
+	e, ok := i.profiles[name]
+	if !ok {
+		e = &ProfileInventoryEntry{Name: name, Issues: make([]string, 0)}
+		i.profiles[name] = e
+	}
+	return e
+}
@@ -0,0 +1,128 @@
+package config
+
+import "testing"
+
+func TestNewProfileInventory(t *testing.T) {
+	t.Run("good sources", func(t *testing.T) {
+		i, err := NewProfileInventory(ConfFileName, credFileName)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer i.Close()
+
+		if len(i.Profiles()) < 8 {
+			t.Error("did not find expected number of profiles")
+		}
+	})
+
+	t.Run("bad config source", func(t *testing.T) {
+		if _, err := NewProfileInventory("not-a-file", credFileName); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("bad credentials source", func(t *testing.T) {
+		if _, err := NewProfileInventory(ConfFileName, "not-a-file"); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestProfileInventory_Profile(t *testing.T) {
+	cfg := []byte(`
+[default]
+region = us-east-1
+
+[profile both]
+region = us-east-2
+
+[profile cfg-only]
+region = us-west-2
+
+[dup]
+region = us-west-1
+
+[profile dup]
+region = us-west-1
+`)
+
+	creds := []byte(`
+[default]
+aws_access_key_id = AKIAMOCK
+aws_secret_access_key = MockSecret
+
+[both]
+aws_access_key_id = AKIAMOCK
+
+[profile creds-only]
+aws_access_key_id = AKIAMOCK
+aws_secret_access_key = MockSecret
+`)
+
+	i, err := NewProfileInventory(cfg, creds)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("default", func(t *testing.T) {
+		e, ok := i.Profile(DefaultProfileName)
+		if !ok || !e.HasConfig() || !e.HasCredentials() || !e.StaticKeys || len(e.Issues) > 0 {
+			t.Errorf("data mismatch: %+v", e)
+		}
+	})
+
+	t.Run("both", func(t *testing.T) {
+		e, ok := i.Profile("both")
+		if !ok || !e.HasConfig() || !e.HasCredentials() || e.StaticKeys {
+			t.Errorf("data mismatch: %+v", e)
+		}
+	})
+
+	t.Run("config only", func(t *testing.T) {
+		e, ok := i.Profile("cfg-only")
+		if !ok || !e.HasConfig() || e.HasCredentials() {
+			t.Errorf("data mismatch: %+v", e)
+		}
+	})
+
+	t.Run("credentials only", func(t *testing.T) {
+		e, ok := i.Profile("creds-only")
+		if !ok || e.HasConfig() || !e.HasCredentials() || len(e.Issues) != 1 {
+			t.Errorf("data mismatch: %+v", e)
+		}
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		e, ok := i.Profile("dup")
+		if !ok || len(e.Issues) != 2 {
+			t.Errorf("data mismatch: %+v", e)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		if _, ok := i.Profile("nope"); ok {
+			t.Error("unexpectedly found profile")
+		}
+	})
+
+	t.Run("sections unmodified", func(t *testing.T) {
+		s, err := i.CredentialProvider.Profile("both")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s.HasKey("aws_secret_access_key") || len(s.Keys()) != 1 {
+			t.Errorf("inventory added keys to credentials section: %v", s.KeyStrings())
+		}
+	})
+}
+
+func TestProfileInventory_Close(t *testing.T) {
+	t.Run("nil providers", func(t *testing.T) {
+		if err := NewProfileInventoryFromProviders(nil, nil).Close(); err != nil {
+			t.Error(err)
+		}
+	})
+}
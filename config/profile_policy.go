@@ -0,0 +1,101 @@
+package config
+
+// ProfilePolicy defines how a profile name is selected when the caller does not provide one, and is applied by all of
+// the providers and the resolver in this package.  The default policy matches the AWS CLI:
+//
+//  1. an explicitly provided, non-empty, profile name is always used as-is
+//  2. the value of the AWS_DEFAULT_PROFILE environment variable
+//  3. the value of the AWS_PROFILE environment variable
+//  4. the "default" profile
+//
+// Environment variables set to an empty value are treated as unset.  The AWS SDK for Go checks AWS_PROFILE before
+// AWS_DEFAULT_PROFILE, which can be configured using WithEnvVars(ProfileEnvVar, DefaultProfileEnvVar).
+type ProfilePolicy struct {
+	envVars     []string
+	defaultName string
+	env         Environment
+}
+
+// DefaultProfilePolicy is the policy used by providers and resolvers which have not been configured with a policy,
+// and by the ResolveProfile() function
+var DefaultProfilePolicy = NewProfilePolicy()
+
+// NewProfilePolicy creates a ProfilePolicy using the AWS CLI resolution rules, which looks up environment variables
+// in the process environment
+func NewProfilePolicy() *ProfilePolicy {
+	return &ProfilePolicy{
+		envVars:     []string{DefaultProfileEnvVar, ProfileEnvVar},
+		defaultName: DefaultProfileName,
+		env:         OsEnvironment{},
+	}
+}
+
+// WithEnvVars is a fluent method for setting the environment variables checked for the profile name, in order
+func (p *ProfilePolicy) WithEnvVars(names ...string) *ProfilePolicy {
+	p.envVars = names
+	return p
+}
+
+// WithDefaultName is a fluent method for setting the profile name used if no profile name is found in the environment
+func (p *ProfilePolicy) WithDefaultName(name string) *ProfilePolicy {
+	p.defaultName = name
+	return p
+}
+
+// WithEnvironment is a fluent method for setting the Environment used to look up environment variables, so the policy
+// can be used without reading the process environment
+func (p *ProfilePolicy) WithEnvironment(env Environment) *ProfilePolicy {
+	p.env = env
+	return p
+}
+
+// Resolve returns the name of the profile to use.  If name is not empty it is returned, otherwise the profile name is
+// looked up in the environment, falling back to the policy default name.
+func (p *ProfilePolicy) Resolve(name string) string {
+	if len(name) > 0 {
+		return name
+	}
+
+	if n, ok := p.FromEnv(); ok {
+		return n
+	}
+	return p.DefaultName()
+}
+
+// FromEnv returns the profile name found in the environment, and a boolean indicating if a name was found
+func (p *ProfilePolicy) FromEnv() (string, bool) {
+	if p.env == nil {
+		return "", false
+	}
+
+	for _, e := range p.envVars {
+		if n, ok := p.env.LookupEnv(e); ok && len(n) > 0 {
+			return n, true
+		}
+	}
+	return "", false
+}
+
+// DefaultName returns the profile name used when no profile name is found in the environment
+func (p *ProfilePolicy) DefaultName() string {
+	if len(p.defaultName) < 1 {
+		return DefaultProfileName
+	}
+	return p.defaultName
+}
+
+// policyOrDefault returns the policy, or the DefaultProfilePolicy if the policy is nil
+func policyOrDefault(p *ProfilePolicy) *ProfilePolicy {
+	if p == nil {
+		return DefaultProfilePolicy
+	}
+	return p
+}
+
+// firstOrEmpty returns the first element of the variadic profile argument used by the providers, or an empty string
+func firstOrEmpty(profile []string) string {
+	if len(profile) > 0 {
+		return profile[0]
+	}
+	return ""
+}
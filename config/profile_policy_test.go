@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProfilePolicy_Resolve(t *testing.T) {
+	t.Run("explicit", func(t *testing.T) {
+		p := NewProfilePolicy().WithEnvironment(MapEnvironment(map[string]string{ProfileEnvVar: "env"}))
+		if p.Resolve("explicit") != "explicit" {
+			t.Error("explicit profile not used")
+		}
+	})
+
+	t.Run("cli order", func(t *testing.T) {
+		p := NewProfilePolicy().WithEnvironment(MapEnvironment(map[string]string{ProfileEnvVar: "p", DefaultProfileEnvVar: "dp"}))
+		if p.Resolve("") != "dp" {
+			t.Error("AWS_DEFAULT_PROFILE not preferred")
+		}
+	})
+
+	t.Run("sdk order", func(t *testing.T) {
+		p := NewProfilePolicy().WithEnvVars(ProfileEnvVar, DefaultProfileEnvVar).
+			WithEnvironment(MapEnvironment(map[string]string{ProfileEnvVar: "p", DefaultProfileEnvVar: "dp"}))
+		if p.Resolve("") != "p" {
+			t.Error("AWS_PROFILE not preferred")
+		}
+	})
+
+	t.Run("empty env", func(t *testing.T) {
+		p := NewProfilePolicy().WithEnvironment(MapEnvironment(map[string]string{DefaultProfileEnvVar: "", ProfileEnvVar: "p"}))
+		if p.Resolve("") != "p" {
+			t.Error("empty env var not ignored")
+		}
+	})
+
+	t.Run("default name", func(t *testing.T) {
+		p := NewProfilePolicy().WithDefaultName("fallback").WithEnvironment(MapEnvironment(map[string]string{}))
+		if p.Resolve("") != "fallback" {
+			t.Error("default name not used")
+		}
+
+		if _, ok := p.FromEnv(); ok {
+			t.Error("unexpected profile from env")
+		}
+	})
+
+	t.Run("process env", func(t *testing.T) {
+		os.Setenv(ProfileEnvVar, "from-os")
+		defer os.Unsetenv(ProfileEnvVar)
+
+		if NewProfilePolicy().Resolve("") != "from-os" || ResolveProfile(nil) != "from-os" {
+			t.Error("process env not used")
+		}
+	})
+}
+
+func TestProfilePolicy_Providers(t *testing.T) {
+	policy := NewProfilePolicy().WithEnvironment(MapEnvironment(map[string]string{ProfileEnvVar: "other"}))
+
+	t.Run("ini config", func(t *testing.T) {
+		p, err := NewIniConfigProvider(ConfFileName)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer p.Close()
+
+		c, err := p.WithProfilePolicy(policy).Config()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Profile != "other" || c.Region != "us-west-1" {
+			t.Error("data mismatch")
+		}
+	})
+
+	t.Run("ini credentials", func(t *testing.T) {
+		p, err := NewIniCredentialProvider(credFileName)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer p.Close()
+
+		v, err := p.WithProfilePolicy(policy).Credentials()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if v.AccessKeyID != "AKIA0THER" {
+			t.Error("data mismatch")
+		}
+	})
+
+	t.Run("env config", func(t *testing.T) {
+		c, err := NewEnvConfigProvider().WithProfilePolicy(policy).Config()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Profile != "other" {
+			t.Error("data mismatch")
+		}
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		b := NewProfileBuilder().Profile("other").Region("us-west-1").Credentials("ak", "sk")
+
+		c, err := b.ConfigProvider().WithProfilePolicy(policy).Config()
+		if err != nil || c.Region != "us-west-1" {
+			t.Error("config mismatch")
+		}
+
+		v, err := b.CredentialProvider().WithProfilePolicy(policy).Credentials()
+		if err != nil || v.AccessKeyID != "ak" {
+			t.Error("credential mismatch")
+		}
+	})
+
+	t.Run("resolver", func(t *testing.T) {
+		p := NewProfilePolicy().WithEnvironment(MapEnvironment(map[string]string{ProfileEnvVar: "mfa"}))
+
+		r, err := NewAwsConfigResolver(ConfFileName)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		c, err := r.WithProfilePolicy(p).Resolve()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		// mfa_serial is merged from the default profile
+		if c.Profile != "mfa" || c.Region != "ap-southeast-2" || len(c.MfaSerial) < 1 {
+			t.Error("data mismatch")
+		}
+	})
+}
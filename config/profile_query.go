@@ -0,0 +1,131 @@
+package config
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ProfileKind describes the type of credentials a profile is configured to use
+type ProfileKind string
+
+const (
+	// ProfileKindNone is a profile without any credential configuration (region or other settings only)
+	ProfileKindNone ProfileKind = ""
+	// ProfileKindRole is a profile which assumes an IAM role (has the role_arn attribute)
+	ProfileKindRole ProfileKind = "role"
+	// ProfileKindSso is a profile which uses AWS SSO credentials
+	ProfileKindSso ProfileKind = "sso"
+	// ProfileKindStatic is a profile with static access keys
+	ProfileKindStatic ProfileKind = "static"
+	// ProfileKindProcess is a profile which uses an external credential_process
+	ProfileKindProcess ProfileKind = "process"
+)
+
+// ProfileEntry is the metadata for a single profile returned by a profile query
+type ProfileEntry struct {
+	// Name is the profile name, without any "profile " prefix
+	Name string `json:"name" yaml:"name"`
+	// Section is the name of the section (or other data item) holding the profile data
+	Section string `json:"section" yaml:"section"`
+	// Kind is the type of credentials the profile is configured to use
+	Kind ProfileKind `json:"kind,omitempty" yaml:"kind,omitempty"`
+	// Source is the location the profile was found (a file path, url, or provider specific name)
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+}
+
+// ProfileQuery defines the criteria used to filter profiles.  All non-empty criteria must match for a profile to
+// be included in the query result.  The zero value matches every profile.
+type ProfileQuery struct {
+	// Name is a glob pattern matched against the profile name.  The '*' character matches any sequence of characters,
+	// and '?' matches a single character
+	Name string
+	// Kind limits results to profiles of the given kind, if set
+	Kind ProfileKind
+	// Attributes is a map of attribute names to glob patterns matched against the attribute's value.  An empty pattern
+	// only requires the attribute to be present in the profile
+	Attributes map[string]string
+}
+
+// Matches returns true if the profile name and attributes satisfy the query criteria
+func (q *ProfileQuery) Matches(name string, attrs map[string]string) bool {
+	if q == nil {
+		return true
+	}
+
+	if len(q.Name) > 0 && !globMatch(q.Name, name) {
+		return false
+	}
+
+	if len(q.Kind) > 0 && profileKind(attrs) != q.Kind {
+		return false
+	}
+
+	for k, v := range q.Attributes {
+		a, ok := attrs[k]
+		if !ok {
+			return false
+		}
+
+		if len(v) > 0 && !globMatch(v, a) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// profileKind inspects the attributes for a profile to determine the kind of credentials it's configured to use
+func profileKind(attrs map[string]string) ProfileKind {
+	has := func(k ...string) bool {
+		for _, x := range k {
+			if len(attrs[x]) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case has("role_arn"):
+		return ProfileKindRole
+	case has("sso_start_url", "sso_session", "sso_account_id"):
+		return ProfileKindSso
+	case has("credential_process"):
+		return ProfileKindProcess
+	case has("aws_access_key_id"):
+		return ProfileKindStatic
+	}
+	return ProfileKindNone
+}
+
+// sortProfileEntries sorts the entries by profile name, and section name for entries with the same profile name
+func sortProfileEntries(e []ProfileEntry) {
+	sort.SliceStable(e, func(i, j int) bool {
+		if e[i].Name == e[j].Name {
+			return e[i].Section < e[j].Section
+		}
+		return e[i].Name < e[j].Name
+	})
+}
+
+// globMatch is a simple glob implementation where '*' and '?' are the only special characters.  Unlike path.Match,
+// the '/' character is not treated specially, so patterns can be used with ARN values
+func globMatch(pattern, s string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+
+	m, err := regexp.MatchString(b.String(), s)
+	return err == nil && m
+}
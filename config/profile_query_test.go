@@ -0,0 +1,71 @@
+package config
+
+import "testing"
+
+func TestProfileQuery_Matches(t *testing.T) {
+	attrs := map[string]string{
+		"region":   "us-east-1",
+		"role_arn": "arn:aws:iam::123456789012:role/Admin",
+	}
+
+	t.Run("nil query", func(t *testing.T) {
+		var q *ProfileQuery
+		if !q.Matches("x", attrs) {
+			t.Error("nil query did not match")
+		}
+	})
+
+	t.Run("name glob", func(t *testing.T) {
+		q := &ProfileQuery{Name: "prod-*"}
+		if !q.Matches("prod-admin", attrs) || q.Matches("dev-admin", attrs) {
+			t.Error("name glob mismatch")
+		}
+	})
+
+	t.Run("attribute present", func(t *testing.T) {
+		q := &ProfileQuery{Attributes: map[string]string{"role_arn": ""}}
+		if !q.Matches("x", attrs) || q.Matches("x", map[string]string{}) {
+			t.Error("attribute presence mismatch")
+		}
+	})
+
+	t.Run("attribute glob", func(t *testing.T) {
+		q := &ProfileQuery{Attributes: map[string]string{"role_arn": "*:role/Admin", "region": "us-*"}}
+		if !q.Matches("x", attrs) {
+			t.Error("attribute glob did not match")
+		}
+
+		q.Attributes["region"] = "eu-?est-1"
+		if q.Matches("x", attrs) {
+			t.Error("attribute glob unexpectedly matched")
+		}
+	})
+
+	t.Run("kind", func(t *testing.T) {
+		q := &ProfileQuery{Kind: ProfileKindRole}
+		if !q.Matches("x", attrs) {
+			t.Error("kind mismatch")
+		}
+
+		q.Kind = ProfileKindSso
+		if q.Matches("x", attrs) {
+			t.Error("kind unexpectedly matched")
+		}
+	})
+}
+
+func TestProfileKind(t *testing.T) {
+	tests := map[ProfileKind]map[string]string{
+		ProfileKindNone:    {"region": "us-east-1"},
+		ProfileKindRole:    {"role_arn": "arn:aws:iam::123456789012:role/Admin", "credential_process": "x"},
+		ProfileKindSso:     {"sso_start_url": "https://example.awsapps.com/start"},
+		ProfileKindProcess: {"credential_process": "/bin/creds"},
+		ProfileKindStatic:  {"aws_access_key_id": "AKIAMOCK"},
+	}
+
+	for k, v := range tests {
+		if profileKind(v) != k {
+			t.Errorf("expected kind '%s' for %v", k, v)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// redactedMask is the replacement for secret values in formatted output
+const redactedMask = "****"
+
+// keyIdPrefixLength is the number of characters of an access key shown in redacted output, which is enough to tell
+// the type of key (AKIA for long term keys, ASIA for session keys)
+const keyIdPrefixLength = 4
+
+// sensitiveAttributes are the ini attributes holding secret values.  The access key is not secret on its own, but is
+// shortened to the prefix in redacted output.
+var sensitiveAttributes = map[string]bool{
+	"aws_secret_access_key": true,
+	"aws_session_token":     true,
+	"aws_security_token":    true,
+}
+
+// Redact returns the redacted form of the attribute value: secrets are masked, access keys are shortened to their
+// prefix, and other values are returned unchanged
+func Redact(attr, value string) string {
+	switch {
+	case len(value) < 1:
+		return value
+	case sensitiveAttributes[attr]:
+		return redactedMask
+	case attr == "aws_access_key_id":
+		return redactKeyId(value)
+	}
+	return value
+}
+
+func redactKeyId(id string) string {
+	if len(id) <= keyIdPrefixLength {
+		return redactedMask
+	}
+	return id[:keyIdPrefixLength] + redactedMask
+}
+
+func redactSecret(s string) string {
+	if len(s) < 1 {
+		return s
+	}
+	return redactedMask
+}
+
+// GetRedacted will return the value of the INI config attribute name specified in attr, with secret values masked.
+// This should be used instead of Get() when the value may be logged or displayed.
+func (c *AwsConfig) GetRedacted(attr string) string {
+	return Redact(attr, c.Get(attr))
+}
+
+// redacted returns a copy of the config with secret raw attributes masked
+func (c AwsConfig) redacted() AwsConfig {
+	if len(c.rawAttributes) > 0 {
+		m := make(map[string]string, len(c.rawAttributes))
+		for k, v := range c.rawAttributes {
+			m[k] = Redact(k, v)
+		}
+		c.rawAttributes = m
+	}
+	return c
+}
+
+// String returns the fmt %v representation of the config, with secret values masked
+func (c AwsConfig) String() string {
+	return fmt.Sprint(c)
+}
+
+// GoString returns the fmt %#v representation of the config, with secret values masked
+func (c AwsConfig) GoString() string {
+	type plain AwsConfig
+	return goString("config.AwsConfig", plain(c.redacted()))
+}
+
+// Format implements fmt.Formatter, so secret values are masked in all fmt output of the config (including %+v and
+// %#v).  Profile and other non-secret values are unchanged.
+func (c AwsConfig) Format(f fmt.State, verb rune) {
+	if isGoSyntax(f, verb) {
+		io.WriteString(f, c.GoString())
+		return
+	}
+
+	type plain AwsConfig
+	fmt.Fprintf(f, directive(f, verb), plain(c.redacted()))
+}
+
+// String returns the fmt %v representation of the credentials, with secret values masked
+func (c awsCredentials) String() string {
+	return fmt.Sprint(c)
+}
+
+// GoString returns the fmt %#v representation of the credentials, with secret values masked
+func (c awsCredentials) GoString() string {
+	type plain awsCredentials
+	return goString("config.awsCredentials", plain(c.redacted()))
+}
+
+// Format implements fmt.Formatter, so secret values are masked in all fmt output of the credentials
+func (c awsCredentials) Format(f fmt.State, verb rune) {
+	if isGoSyntax(f, verb) {
+		io.WriteString(f, c.GoString())
+		return
+	}
+
+	type plain awsCredentials
+	fmt.Fprintf(f, directive(f, verb), plain(c.redacted()))
+}
+
+// redacted returns a copy of the credentials with secret values masked
+func (c awsCredentials) redacted() awsCredentials {
+	return awsCredentials{
+		AccessKey:    redactKeyId(c.AccessKey),
+		SecretKey:    redactSecret(c.SecretKey),
+		SessionToken: redactSecret(c.SessionToken),
+	}
+}
+
+// RedactedValue wraps the AWS SDK credentials.Value type, so it can be formatted or logged with secret values masked.
+//
+//	log.Printf("using credentials %+v", RedactedValue(v))
+type RedactedValue credentials.Value
+
+// String returns the fmt %v representation of the credentials, with secret values masked
+func (v RedactedValue) String() string {
+	return fmt.Sprint(v)
+}
+
+// GoString returns the fmt %#v representation of the credentials, with secret values masked
+func (v RedactedValue) GoString() string {
+	return goString("config.RedactedValue", v.redacted())
+}
+
+// Format implements fmt.Formatter, so secret values are masked in all fmt output of the credentials
+func (v RedactedValue) Format(f fmt.State, verb rune) {
+	if isGoSyntax(f, verb) {
+		io.WriteString(f, v.GoString())
+		return
+	}
+	fmt.Fprintf(f, directive(f, verb), v.redacted())
+}
+
+// redacted returns the credentials with secret values masked
+func (v RedactedValue) redacted() credentials.Value {
+	return credentials.Value{
+		AccessKeyID:     redactKeyId(v.AccessKeyID),
+		SecretAccessKey: redactSecret(v.SecretAccessKey),
+		SessionToken:    redactSecret(v.SessionToken),
+		ProviderName:    v.ProviderName,
+	}
+}
+
+// String returns the fmt %v representation of the output, with secret values masked
+func (o CredentialProcessOutput) String() string {
+	return fmt.Sprint(o)
+}
+
+// GoString returns the fmt %#v representation of the output, with secret values masked
+func (o CredentialProcessOutput) GoString() string {
+	type plain CredentialProcessOutput
+	return goString("config.CredentialProcessOutput", plain(o.redacted()))
+}
+
+// Format implements fmt.Formatter, so secret values are masked in all fmt output of the credential_process output.
+// Use json.Marshal() to create the credential_process document.
+func (o CredentialProcessOutput) Format(f fmt.State, verb rune) {
+	if isGoSyntax(f, verb) {
+		io.WriteString(f, o.GoString())
+		return
+	}
+
+	type plain CredentialProcessOutput
+	fmt.Fprintf(f, directive(f, verb), plain(o.redacted()))
+}
+
+// redacted returns a copy of the output with secret values masked
+func (o CredentialProcessOutput) redacted() CredentialProcessOutput {
+	o.AccessKeyId = redactKeyId(o.AccessKeyId)
+	o.SecretAccessKey = redactSecret(o.SecretAccessKey)
+	o.SessionToken = redactSecret(o.SessionToken)
+	return o
+}
+
+// isGoSyntax returns true for the %#v directive, which is formatted using GoString()
+func isGoSyntax(f fmt.State, verb rune) bool {
+	return verb == 'v' && f.Flag('#')
+}
+
+// goString returns the %#v representation of the value, using the type name in place of the name of the value's type.
+// This is used to print the name of the redacted type, instead of the local type used to avoid Format() recursion.
+func goString(name string, v interface{}) string {
+	s := fmt.Sprintf("%#v", v)
+	return name + s[strings.Index(s, "{"):]
+}
+
+// directive rebuilds the format directive (like %+v or %-10s) from the fmt.State
+func directive(f fmt.State, verb rune) string {
+	d := []byte{'%'}
+	for _, c := range "+-# 0" {
+		if f.Flag(int(c)) {
+			d = append(d, byte(c))
+		}
+	}
+
+	if w, ok := f.Width(); ok {
+		d = strconv.AppendInt(d, int64(w), 10)
+	}
+
+	if p, ok := f.Precision(); ok {
+		d = append(d, '.')
+		d = strconv.AppendInt(d, int64(p), 10)
+	}
+	return string(append(d, string(verb)...))
+}
@@ -0,0 +1,54 @@
+//go:build go1.21
+// +build go1.21
+
+package config
+
+import (
+	"log/slog"
+	"sort"
+)
+
+// LogValue implements slog.LogValuer, logging the profile and attributes of the config with secret values masked
+func (c AwsConfig) LogValue() slog.Value {
+	keys := make([]string, 0, len(c.rawAttributes))
+	for k := range c.rawAttributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := []slog.Attr{slog.String("profile", c.Profile)}
+	for _, k := range keys {
+		attrs = append(attrs, slog.String(k, Redact(k, c.rawAttributes[k])))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LogValue implements slog.LogValuer, logging the credentials with secret values masked
+func (c awsCredentials) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("aws_access_key_id", redactKeyId(c.AccessKey)),
+		slog.String("aws_secret_access_key", redactSecret(c.SecretKey)),
+		slog.String("aws_session_token", redactSecret(c.SessionToken)),
+	)
+}
+
+// LogValue implements slog.LogValuer, logging the credentials with secret values masked
+func (v RedactedValue) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("access_key_id", redactKeyId(v.AccessKeyID)),
+		slog.String("secret_access_key", redactSecret(v.SecretAccessKey)),
+		slog.String("session_token", redactSecret(v.SessionToken)),
+		slog.String("provider", v.ProviderName),
+	)
+}
+
+// LogValue implements slog.LogValuer, logging the credential_process output with secret values masked
+func (o CredentialProcessOutput) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("version", o.Version),
+		slog.String("access_key_id", redactKeyId(o.AccessKeyId)),
+		slog.String("secret_access_key", redactSecret(o.SecretAccessKey)),
+		slog.String("session_token", redactSecret(o.SessionToken)),
+		slog.String("expiration", o.Expiration),
+	)
+}
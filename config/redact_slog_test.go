@@ -0,0 +1,30 @@
+//go:build go1.21
+// +build go1.21
+
+package config
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	b := new(bytes.Buffer)
+	l := slog.New(slog.NewTextHandler(b, nil))
+
+	c := AwsConfig{Profile: "p", rawAttributes: map[string]string{"region": "us-east-1", "aws_secret_access_key": "supersecret"}}
+	v := credentials.Value{AccessKeyID: "AKIAEXAMPLEKEY", SecretAccessKey: "supersecret"}
+	l.Info("test", "config", c, "creds", RedactedValue(v))
+
+	s := b.String()
+	if strings.Contains(s, "supersecret") || strings.Contains(s, "EXAMPLEKEY") {
+		t.Errorf("secret in log output: %s", s)
+	}
+
+	if !strings.Contains(s, "config.region=us-east-1") || !strings.Contains(s, "creds.access_key_id=AKIA****") {
+		t.Errorf("missing data in log output: %s", s)
+	}
+}
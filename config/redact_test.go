@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"strings"
+	"testing"
+)
+
+func TestAwsConfig_Format(t *testing.T) {
+	c := &AwsConfig{Profile: "p", Region: "us-east-1", rawAttributes: map[string]string{
+		"region":                "us-east-1",
+		"aws_access_key_id":     "AKIAEXAMPLEKEY",
+		"aws_secret_access_key": "supersecret",
+		"aws_session_token":     "supertoken",
+	}}
+
+	for _, f := range []string{"%v", "%+v", "%#v", "%s", "%20v"} {
+		s := fmt.Sprintf(f, c)
+		if strings.Contains(s, "supersecret") || strings.Contains(s, "supertoken") || strings.Contains(s, "EXAMPLEKEY") {
+			t.Errorf("secret in %s output: %s", f, s)
+		}
+
+		if !strings.Contains(s, "us-east-1") || !strings.Contains(s, "AKIA****") {
+			t.Errorf("missing data in %s output: %s", f, s)
+		}
+	}
+
+	if s := fmt.Sprintf("%#v", c); !strings.HasPrefix(s, "config.AwsConfig{") || s != c.GoString() {
+		t.Errorf("unexpected %%#v output: %s", s)
+	}
+
+	if c.Get("aws_secret_access_key") != "supersecret" {
+		t.Error("raw attribute modified")
+	}
+
+	if c.GetRedacted("aws_secret_access_key") != redactedMask || c.GetRedacted("region") != "us-east-1" ||
+		c.GetRedacted("aws_access_key_id") != "AKIA****" || len(c.GetRedacted("not_set")) > 0 {
+		t.Error("unexpected redacted value")
+	}
+}
+
+func TestRedactedValue_Format(t *testing.T) {
+	v := credentials.Value{AccessKeyID: "ASIAEXAMPLEKEY", SecretAccessKey: "supersecret", SessionToken: "supertoken", ProviderName: "test"}
+
+	for _, f := range []string{"%v", "%+v", "%#v", "%s"} {
+		s := fmt.Sprintf(f, RedactedValue(v))
+		if strings.Contains(s, "supersecret") || strings.Contains(s, "supertoken") || strings.Contains(s, "EXAMPLEKEY") {
+			t.Errorf("secret in %s output: %s", f, s)
+		}
+
+		if !strings.Contains(s, "ASIA****") || !strings.Contains(s, "test") {
+			t.Errorf("missing data in %s output: %s", f, s)
+		}
+	}
+
+	c := awsCredentials{AccessKey: "AKIAEXAMPLEKEY", SecretKey: "supersecret"}
+	if s := fmt.Sprintf("%+v", c); s != "{AccessKey:AKIA**** SecretKey:**** SessionToken:}" {
+		t.Errorf("unexpected output: %s", s)
+	}
+
+	if s := fmt.Sprintf("%#v", c); s != `config.awsCredentials{AccessKey:"AKIA****", SecretKey:"****", SessionToken:""}` {
+		t.Errorf("unexpected output: %s", s)
+	}
+
+	if s := fmt.Sprintf("%#v", RedactedValue(v)); !strings.HasPrefix(s, "config.RedactedValue{") {
+		t.Errorf("unexpected output: %s", s)
+	}
+
+	o := CredentialProcessOutput{Version: 1, AccessKeyId: "AKIAEXAMPLEKEY", SecretAccessKey: "supersecret"}
+	if s := o.String(); strings.Contains(s, "supersecret") || !strings.Contains(s, "AKIA****") {
+		t.Errorf("unexpected output: %s", s)
+	}
+
+	if s := fmt.Sprintf("%#v", o); strings.Contains(s, "supersecret") || !strings.HasPrefix(s, "config.CredentialProcessOutput{") {
+		t.Errorf("unexpected output: %s", s)
+	}
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"time"
+)
+
+// IamAccessKeyClient is the subset of the AWS SDK IAM API used to rotate access keys.  The *iam.IAM type (and the
+// iamiface.IAMAPI interface) satisfy this interface, allowing a fake implementation to be used for testing.
+type IamAccessKeyClient interface {
+	CreateAccessKey(input *iam.CreateAccessKeyInput) (*iam.CreateAccessKeyOutput, error)
+	UpdateAccessKey(input *iam.UpdateAccessKeyInput) (*iam.UpdateAccessKeyOutput, error)
+	DeleteAccessKey(input *iam.DeleteAccessKeyInput) (*iam.DeleteAccessKeyOutput, error)
+}
+
+// IamClientFactory is a function returning an IamAccessKeyClient authenticated using the credentials
+type IamClientFactory func(creds credentials.Value) IamAccessKeyClient
+
+// SessionIamClientFactory returns an IamClientFactory creating *iam.IAM clients using the configuration of the session
+// (like the region), and the provided credentials
+func SessionIamClientFactory(s *session.Session) IamClientFactory {
+	return func(creds credentials.Value) IamAccessKeyClient {
+		return iam.New(s, aws.NewConfig().WithCredentials(credentials.NewStaticCredentialsFromCreds(creds)))
+	}
+}
+
+// KeyVerifier is a function which checks that the newly created credentials are usable, returning an error if not
+type KeyVerifier func(creds credentials.Value) error
+
+// RotationError is returned by Rotate() when a step of the rotation fails.  Step is the name of the failed step
+// (create, verify, save, deactivate, or delete), and RollbackErr is set if restoring the original key failed, in
+// which case manual cleanup of the access keys and credentials file may be required.
+type RotationError struct {
+	Step        string
+	Err         error
+	RollbackErr error
+}
+
+func (e *RotationError) Error() string {
+	if e.RollbackErr != nil {
+		return fmt.Sprintf("key rotation failed at %s step: %v (rollback failed: %v)", e.Step, e.Err, e.RollbackErr)
+	}
+	return fmt.Sprintf("key rotation failed at %s step: %v", e.Step, e.Err)
+}
+
+// Unwrap returns the error of the failed step
+func (e *RotationError) Unwrap() error {
+	return e.Err
+}
+
+// StsKeyVerifier returns a KeyVerifier which calls the STS GetCallerIdentity API in the region using the credentials.
+// The region must be in the partition of the account owning the keys (for example, cn-north-1 for aws-cn accounts),
+// and us-east-1 is used if it is empty.  Since IAM is eventually consistent, newly created keys may not be usable
+// right away, so the call is retried until it succeeds or the timeout expires.
+func StsKeyVerifier(region string, timeout time.Duration) KeyVerifier {
+	if len(region) < 1 {
+		region = "us-east-1"
+	}
+
+	return func(creds credentials.Value) error {
+		s, err := session.NewSession(aws.NewConfig().
+			WithCredentials(credentials.NewStaticCredentialsFromCreds(creds)).WithRegion(region))
+		if err != nil {
+			return err
+		}
+		c := sts.New(s)
+
+		deadline := time.Now().Add(timeout)
+		for {
+			_, err = c.GetCallerIdentity(new(sts.GetCallerIdentityInput))
+			if err == nil || time.Now().After(deadline) {
+				return err
+			}
+			time.Sleep(2 * time.Second)
+		}
+	}
+}
+
+// Rotate replaces the access key of the profile with a new key.  The steps of the rotation are:
+//  1. create a new access key using a client authenticated with the old key
+//  2. verify the new key using the verifier (if nil, an StsKeyVerifier in the region of the client, with a 30 second
+//     timeout, is used)
+//  3. update the profile with the new key, and save the credentials file (atomically, using a rename)
+//  4. deactivate the old access key, using a client authenticated with the new key
+//  5. delete the old access key, using a client authenticated with the new key
+//
+// If any step fails, the previous steps are rolled back: the old key is re-activated, the credentials file is restored,
+// and the new key is deleted.  The returned error is a *RotationError.  The clients are created by the factory using
+// the credentials of the profile, and the new credentials once they are verified, since IAM rejects requests signed
+// with the old key after it's deactivated.  Keys are managed for the IAM user the clients are authenticated as.
+// The provider must be loaded from a local file, and the profile must contain static (non-session) credentials.
+func (p *IniCredentialProvider) Rotate(profile string, newClient IamClientFactory, verify KeyVerifier) (*iam.AccessKey, error) {
+	if !p.Writable() {
+		return nil, fmt.Errorf("credentials source is not a writable file")
+	}
+
+	old, err := p.Credentials(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(old.SessionToken) > 0 {
+		return nil, fmt.Errorf("profile '%s' contains session credentials, which can not be rotated", profile)
+	}
+
+	client := newClient(old)
+	if verify == nil {
+		verify = StsKeyVerifier(clientRegion(client), 30*time.Second)
+	}
+
+	oldCreated, err := p.KeyCreatedAt(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	log := p.logger()
+	log.Debug("creating new access key", "profile", profile)
+
+	out, err := client.CreateAccessKey(new(iam.CreateAccessKeyInput))
+	if err != nil {
+		return nil, &RotationError{Step: "create", Err: err}
+	}
+	key := out.AccessKey
+
+	// rollback steps, run in reverse order.  The old key is active again when the new key is deleted, so the client
+	// authenticated with the old key is used.
+	undo := []func() error{func() error {
+		_, err := client.DeleteAccessKey(&iam.DeleteAccessKeyInput{AccessKeyId: key.AccessKeyId})
+		return err
+	}}
+
+	fail := func(step string, err error) (*iam.AccessKey, error) {
+		log.Debug("key rotation failed, rolling back", "profile", profile, "step", step)
+
+		e := &RotationError{Step: step, Err: err}
+		for i := len(undo) - 1; i >= 0; i-- {
+			if rErr := undo[i](); rErr != nil && e.RollbackErr == nil {
+				e.RollbackErr = rErr
+			}
+		}
+		return nil, e
+	}
+
+	newCreds := credentials.Value{AccessKeyID: aws.StringValue(key.AccessKeyId), SecretAccessKey: aws.StringValue(key.SecretAccessKey)}
+	if err := verify(newCreds); err != nil {
+		return fail("verify", err)
+	}
+	newKeyClient := newClient(newCreds)
+
+	undo = append(undo, func() error {
+		if err := p.restoreCredentials(profile, old, oldCreated); err != nil {
+			return err
+		}
+		return p.saveAtomic(p.Path)
+	})
+
+	if err := p.UpdateCredentials(profile, key); err != nil {
+		return fail("save", err)
+	}
+
+	if err := p.saveAtomic(p.Path); err != nil {
+		return fail("save", err)
+	}
+
+	log.Debug("deactivating old access key", "profile", profile)
+	_, err = newKeyClient.UpdateAccessKey(&iam.UpdateAccessKeyInput{
+		AccessKeyId: aws.String(old.AccessKeyID),
+		Status:      aws.String(iam.StatusTypeInactive),
+	})
+	if err != nil {
+		return fail("deactivate", err)
+	}
+
+	undo = append(undo, func() error {
+		_, err := newKeyClient.UpdateAccessKey(&iam.UpdateAccessKeyInput{
+			AccessKeyId: aws.String(old.AccessKeyID),
+			Status:      aws.String(iam.StatusTypeActive),
+		})
+		return err
+	})
+
+	log.Debug("deleting old access key", "profile", profile)
+	if _, err := newKeyClient.DeleteAccessKey(&iam.DeleteAccessKeyInput{AccessKeyId: aws.String(old.AccessKeyID)}); err != nil {
+		return fail("delete", err)
+	}
+
+	return key, nil
+}
+
+// restoreCredentials sets the credentials and key creation time of the profile to the values saved before rotation
+func (p *IniCredentialProvider) restoreCredentials(profile string, creds credentials.Value, created time.Time) error {
+	if err := p.UpdateCredentials(profile, creds); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, err := p.profile(profile, nil)
+	if err != nil {
+		return err
+	}
+
+	if created.IsZero() {
+		s.DeleteKey(KeyCreatedAttribute)
+	} else {
+		s.Key(KeyCreatedAttribute).SetValue(created.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// clientRegion returns the region of the client if it's an *iam.IAM, so keys are verified in the partition the client
+// manages them in, otherwise an empty string
+func clientRegion(client IamAccessKeyClient) string {
+	if c, ok := client.(*iam.IAM); ok {
+		return aws.StringValue(c.Config.Region)
+	}
+	return ""
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIam is an in-memory set of IAM access keys, failing the operation named in failOn
+type fakeIam struct {
+	keys   map[string]string
+	n      int
+	failOn string
+}
+
+func newFakeIam(keys ...string) *fakeIam {
+	f := &fakeIam{keys: make(map[string]string)}
+	for _, k := range keys {
+		f.keys[k] = iam.StatusTypeActive
+	}
+	return f
+}
+
+// client is an IamClientFactory returning a fakeIamClient signing requests with the access key of the credentials
+func (f *fakeIam) client(creds credentials.Value) IamAccessKeyClient {
+	return &fakeIamClient{fakeIam: f, key: creds.AccessKeyID}
+}
+
+// fakeIamClient is an IamAccessKeyClient for the fakeIam keys, rejecting requests if its key is not active
+type fakeIamClient struct {
+	*fakeIam
+	key string
+}
+
+func (c *fakeIamClient) authorize() error {
+	if c.keys[c.key] != iam.StatusTypeActive {
+		return fmt.Errorf("InvalidClientTokenId: the security token included in the request is invalid")
+	}
+	return nil
+}
+
+func (c *fakeIamClient) CreateAccessKey(input *iam.CreateAccessKeyInput) (*iam.CreateAccessKeyOutput, error) {
+	if err := c.authorize(); err != nil {
+		return nil, err
+	}
+
+	if c.failOn == "create" {
+		return nil, fmt.Errorf("create failed")
+	}
+
+	c.n++
+	id := fmt.Sprintf("AKIANEW%d", c.n)
+	c.keys[id] = iam.StatusTypeActive
+
+	k := &iam.AccessKey{AccessKeyId: aws.String(id), SecretAccessKey: aws.String("newsecret"),
+		Status: aws.String(iam.StatusTypeActive), CreateDate: aws.Time(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))}
+	return &iam.CreateAccessKeyOutput{AccessKey: k}, nil
+}
+
+func (c *fakeIamClient) UpdateAccessKey(input *iam.UpdateAccessKeyInput) (*iam.UpdateAccessKeyOutput, error) {
+	if err := c.authorize(); err != nil {
+		return nil, err
+	}
+
+	if c.failOn == "update" {
+		return nil, fmt.Errorf("update failed")
+	}
+
+	if _, ok := c.keys[*input.AccessKeyId]; !ok {
+		return nil, fmt.Errorf("key not found")
+	}
+	c.keys[*input.AccessKeyId] = *input.Status
+	return new(iam.UpdateAccessKeyOutput), nil
+}
+
+func (c *fakeIamClient) DeleteAccessKey(input *iam.DeleteAccessKeyInput) (*iam.DeleteAccessKeyOutput, error) {
+	if err := c.authorize(); err != nil {
+		return nil, err
+	}
+
+	// only fail deleting the old key, so the new key can be deleted during rollback
+	if c.failOn == "delete" && !strings.HasPrefix(*input.AccessKeyId, "AKIANEW") {
+		return nil, fmt.Errorf("delete failed")
+	}
+
+	if _, ok := c.keys[*input.AccessKeyId]; !ok {
+		return nil, fmt.Errorf("key not found")
+	}
+	delete(c.keys, *input.AccessKeyId)
+	return new(iam.DeleteAccessKeyOutput), nil
+}
+
+func rotationFile(t *testing.T) (*IniCredentialProvider, func()) {
+	d, err := ioutil.TempDir("", "rotation")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(d, "credentials")
+	data := []byte("[default]\naws_access_key_id = AKIAOLD\naws_secret_access_key = oldsecret\n")
+	if err := ioutil.WriteFile(fn, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewIniCredentialProvider(fn, WithKeyCreatedTracking(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p, func() { os.RemoveAll(d) }
+}
+
+// fileCredentials returns the credentials of the default profile saved in the provider's file
+func fileCredentials(t *testing.T, p *IniCredentialProvider) credentials.Value {
+	f, err := NewIniCredentialProvider(p.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := f.Credentials(DefaultProfileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func okVerifier(credentials.Value) error { return nil }
+
+func TestIniCredentialProvider_Rotate(t *testing.T) {
+	t.Run("good", func(t *testing.T) {
+		p, cleanup := rotationFile(t)
+		defer cleanup()
+
+		c := newFakeIam("AKIAOLD")
+		k, err := p.Rotate(DefaultProfileName, c.client, okVerifier)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if *k.AccessKeyId != "AKIANEW1" || len(c.keys) != 1 || c.keys["AKIANEW1"] != iam.StatusTypeActive {
+			t.Errorf("unexpected iam keys: %v", c.keys)
+		}
+
+		if v := fileCredentials(t, p); v.AccessKeyID != "AKIANEW1" || v.SecretAccessKey != "newsecret" {
+			t.Errorf("credentials file not updated: %+v", v)
+		}
+
+		if c, _ := p.KeyCreatedAt(DefaultProfileName); !c.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("key creation time not updated: %v", c)
+		}
+
+		if fi, _ := os.Stat(p.Path); fi.Mode().Perm() != 0600 {
+			t.Errorf("file mode not preserved: %v", fi.Mode())
+		}
+	})
+
+	for _, step := range []string{"create", "verify", "deactivate", "delete"} {
+		t.Run(fmt.Sprintf("fail %s", step), func(t *testing.T) {
+			p, cleanup := rotationFile(t)
+			defer cleanup()
+
+			c := newFakeIam("AKIAOLD")
+			verify := okVerifier
+
+			switch step {
+			case "verify":
+				verify = func(credentials.Value) error { return fmt.Errorf("invalid key") }
+			case "deactivate":
+				c.failOn = "update"
+			default:
+				c.failOn = step
+			}
+
+			_, err := p.Rotate(DefaultProfileName, c.client, verify)
+
+			var e *RotationError
+			if !errors.As(err, &e) || e.Step != step || e.RollbackErr != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if len(c.keys) != 1 || c.keys["AKIAOLD"] != iam.StatusTypeActive {
+				t.Errorf("iam keys not rolled back: %v", c.keys)
+			}
+
+			if v, _ := p.Credentials(DefaultProfileName); v.AccessKeyID != "AKIAOLD" {
+				t.Errorf("provider credentials not rolled back: %+v", v)
+			}
+
+			if v := fileCredentials(t, p); v.AccessKeyID != "AKIAOLD" || v.SecretAccessKey != "oldsecret" {
+				t.Errorf("credentials file not rolled back: %+v", v)
+			}
+
+			if c, _ := p.KeyCreatedAt(DefaultProfileName); !c.IsZero() {
+				t.Errorf("key creation time not rolled back: %v", c)
+			}
+		})
+	}
+
+	t.Run("not a file", func(t *testing.T) {
+		p, err := NewIniCredentialProvider([]byte("[default]\naws_access_key_id = AKIAOLD\naws_secret_access_key = oldsecret\n"))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if _, err := p.Rotate(DefaultProfileName, newFakeIam("AKIAOLD").client, okVerifier); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("session credentials", func(t *testing.T) {
+		p, err := NewIniCredentialProvider(credFileName)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if _, err := p.Rotate("token", newFakeIam("accesskey").client, okVerifier); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestClientRegion(t *testing.T) {
+	s, err := session.NewSession(aws.NewConfig().WithRegion("cn-north-1").
+		WithCredentials(credentials.NewStaticCredentials("ak", "sk", "")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := clientRegion(SessionIamClientFactory(s)(credentials.Value{})); r != "cn-north-1" {
+		t.Errorf("unexpected region: %s", r)
+	}
+
+	if r := clientRegion(newFakeIam().client(credentials.Value{})); len(r) > 0 {
+		t.Errorf("unexpected region: %s", r)
+	}
+}
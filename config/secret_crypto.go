@@ -0,0 +1,105 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+	"io"
+)
+
+const (
+	saltSize = 16
+	keySize  = 32
+
+	// scrypt parameters, as recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// deriveKey returns the AES-256 key for the passphrase and salt
+func deriveKey(passphrase, salt []byte) ([]byte, error) {
+	return scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, keySize)
+}
+
+// seal encrypts the plaintext using AES-GCM with a key derived from the passphrase.  The returned data is the magic
+// header, followed by the random salt and nonce, then the ciphertext.  The header is authenticated as additional data.
+func seal(magic, passphrase, plaintext []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return sealWithKey(magic, key, salt, plaintext)
+}
+
+func sealWithKey(magic, key, salt, plaintext []byte) ([]byte, error) {
+	gcm, err := newGcm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 0, len(magic)+len(salt)+len(nonce)+len(plaintext)+gcm.Overhead())
+	b = append(append(append(b, magic...), salt...), nonce...)
+	return gcm.Seal(b, nonce, plaintext, magic), nil
+}
+
+// open decrypts data created by seal()
+func open(magic, passphrase, data []byte) ([]byte, error) {
+	salt, err := sealedSalt(magic, data)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return openWithKey(magic, key, data)
+}
+
+func openWithKey(magic, key, data []byte) ([]byte, error) {
+	gcm, err := newGcm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	hdr := len(magic) + saltSize + gcm.NonceSize()
+	if len(data) < hdr+gcm.Overhead() || !bytes.HasPrefix(data, magic) {
+		return nil, fmt.Errorf("invalid encrypted data")
+	}
+
+	p, err := gcm.Open(nil, data[len(magic)+saltSize:hdr], data[hdr:], magic)
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed, incorrect passphrase or corrupt data")
+	}
+	return p, nil
+}
+
+// sealedSalt returns the salt of data created by seal()
+func sealedSalt(magic, data []byte) ([]byte, error) {
+	if len(data) < len(magic)+saltSize || !bytes.HasPrefix(data, magic) {
+		return nil, fmt.Errorf("invalid encrypted data")
+	}
+	return data[len(magic) : len(magic)+saltSize], nil
+}
+
+func newGcm(key []byte) (cipher.AEAD, error) {
+	b, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(b)
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// SecretStore is an interface defining the contract for conforming types to store secret values outside of the
+// credentials file.  Get returns an error matching ErrSecretNotFound (using errors.Is()) if the key does not exist.
+type SecretStore interface {
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+var secretStoreMagic = []byte("AWSCONFIG-SECRETS-1\n")
+
+// EncryptedFileStore is a SecretStore which keeps the secrets in a single file, encrypted using AES-256-GCM with a key
+// derived from a passphrase using scrypt.  It is safe for concurrent use within a process, but does not protect
+// against concurrent updates from other processes.
+type EncryptedFileStore struct {
+	path       string
+	passphrase []byte
+	salt       []byte
+	key        []byte
+	mu         sync.Mutex
+}
+
+// NewEncryptedFileStore creates an EncryptedFileStore using the file at path, which is created when the first secret
+// is stored.
+func NewEncryptedFileStore(path string, passphrase []byte) *EncryptedFileStore {
+	return &EncryptedFileStore{path: path, passphrase: passphrase}
+}
+
+// Get returns the secret value for the key
+func (s *EncryptedFileStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.load()
+	if err != nil {
+		return "", err
+	}
+
+	v, ok := m[key]
+	if !ok {
+		return "", fmt.Errorf("%w: '%s'", ErrSecretNotFound, key)
+	}
+	return v, nil
+}
+
+// Set stores the secret value for the key, replacing any existing value
+func (s *EncryptedFileStore) Set(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	m[key] = value
+	return s.save(m)
+}
+
+// Delete removes the secret for the key.  Deleting a key which does not exist is not an error.
+func (s *EncryptedFileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := m[key]; !ok {
+		return nil
+	}
+
+	delete(m, key)
+	return s.save(m)
+}
+
+// load reads and decrypts the secrets in the file, returning an empty map if the file does not exist.  The derived
+// key is cached, since it's expensive to compute, and only needs to change if the file salt changes.
+func (s *EncryptedFileStore) load() (map[string]string, error) {
+	m := make(map[string]string)
+
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+
+	salt, err := sealedSalt(secretStoreMagic, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(salt, s.salt) {
+		key, err := deriveKey(s.passphrase, salt)
+		if err != nil {
+			return nil, err
+		}
+		s.salt = append([]byte{}, salt...)
+		s.key = key
+	}
+
+	p, err := openWithKey(secretStoreMagic, s.key, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(p, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *EncryptedFileStore) save(m map[string]string) error {
+	p, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if s.key == nil {
+		data, err = seal(secretStoreMagic, s.passphrase, p)
+	} else {
+		data, err = sealWithKey(secretStoreMagic, s.key, s.salt, p)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeAtomic(s.path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// CommandStore is a SecretStore which uses a pass (https://www.passwordstore.org/) compatible command to manage the
+// secrets.  The command is called using the 'show', 'insert --multiline --force', and 'rm --force' subcommands, so
+// pass, gopass, or a wrapper script supporting those arguments can be used.
+type CommandStore struct {
+	command string
+	prefix  string
+}
+
+// NewCommandStore creates a CommandStore using the named command.  If command is empty, "pass" is used.
+func NewCommandStore(command string) *CommandStore {
+	if len(command) < 1 {
+		command = "pass"
+	}
+	return &CommandStore{command: command}
+}
+
+// WithPrefix is a fluent method for setting the prefix prepended to keys to create the entry name passed to the
+// command, for example "aws/" to store the secrets in the aws folder of the password store
+func (s *CommandStore) WithPrefix(prefix string) *CommandStore {
+	s.prefix = prefix
+	return s
+}
+
+// Get returns the secret value for the key, which is the first line of the command output
+func (s *CommandStore) Get(key string) (string, error) {
+	out, err := s.run(nil, "show", s.prefix+key)
+	if err != nil {
+		return "", err
+	}
+
+	l, err := bufio.NewReader(bytes.NewReader(out)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(l, "\r\n"), nil
+}
+
+// Set stores the secret value for the key, replacing any existing value
+func (s *CommandStore) Set(key, value string) error {
+	_, err := s.run(strings.NewReader(value+"\n"), "insert", "--multiline", "--force", s.prefix+key)
+	return err
+}
+
+// Delete removes the secret for the key
+func (s *CommandStore) Delete(key string) error {
+	_, err := s.run(nil, "rm", "--force", s.prefix+key)
+	return err
+}
+
+func (s *CommandStore) run(stdin io.Reader, args ...string) ([]byte, error) {
+	stderr := new(bytes.Buffer)
+
+	cmd := exec.Command(s.command, args...)
+	cmd.Stdin = stdin
+	cmd.Stderr = stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "not in the password store") {
+			return nil, fmt.Errorf("%w: '%s'", ErrSecretNotFound, args[len(args)-1])
+		}
+		return nil, fmt.Errorf("%s %s: %v: %s", s.command, args[0], err, msg)
+	}
+	return out, nil
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// mapStore is an in-memory SecretStore
+type mapStore map[string]string
+
+func (s mapStore) Get(key string) (string, error) {
+	v, ok := s[key]
+	if !ok {
+		return "", fmt.Errorf("%w: '%s'", ErrSecretNotFound, key)
+	}
+	return v, nil
+}
+
+func (s mapStore) Set(key, value string) error {
+	s[key] = value
+	return nil
+}
+
+func (s mapStore) Delete(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func testSecretStore(t *testing.T, s SecretStore) {
+	if _, err := s.Get("missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := s.Set("aws/one", "secret1"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := s.Set("aws/two", "secret2"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if v, err := s.Get("aws/one"); err != nil || v != "secret1" {
+		t.Errorf("unexpected value '%s': %v", v, err)
+	}
+
+	if err := s.Set("aws/one", "updated"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if v, err := s.Get("aws/one"); err != nil || v != "updated" {
+		t.Errorf("unexpected value '%s': %v", v, err)
+	}
+
+	if err := s.Delete("aws/one"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if _, err := s.Get("aws/one"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if v, err := s.Get("aws/two"); err != nil || v != "secret2" {
+		t.Errorf("unexpected value '%s': %v", v, err)
+	}
+}
+
+func TestEncryptedFileStore(t *testing.T) {
+	d, err := ioutil.TempDir("", "secret-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	fn := filepath.Join(d, "secrets")
+
+	t.Run("store", func(t *testing.T) {
+		testSecretStore(t, NewEncryptedFileStore(fn, []byte("passphrase")))
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if !bytes.HasPrefix(data, secretStoreMagic) || bytes.Contains(data, []byte("secret2")) {
+			t.Error("unexpected file data")
+		}
+
+		if fi, _ := os.Stat(fn); runtime.GOOS != "windows" && fi.Mode().Perm() != 0600 {
+			t.Errorf("unexpected file mode %v", fi.Mode())
+		}
+	})
+
+	t.Run("reopen", func(t *testing.T) {
+		if v, err := NewEncryptedFileStore(fn, []byte("passphrase")).Get("aws/two"); err != nil || v != "secret2" {
+			t.Errorf("unexpected value '%s': %v", v, err)
+		}
+	})
+
+	t.Run("bad passphrase", func(t *testing.T) {
+		if _, err := NewEncryptedFileStore(fn, []byte("wrong")).Get("aws/two"); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+// fakePass is a shell script implementing the pass commands used by the CommandStore, storing secrets as files
+const fakePass = `#!/bin/sh
+dir="$FAKE_PASS_DIR"
+case "$1" in
+show)
+  [ -f "$dir/$2" ] || { echo "Error: $2 is not in the password store." >&2; exit 1; }
+  cat "$dir/$2"; echo "extra line" ;;
+insert)
+  mkdir -p "$(dirname "$dir/$4")"; cat > "$dir/$4" ;;
+rm)
+  [ -f "$dir/$3" ] || { echo "Error: $3 is not in the password store." >&2; exit 1; }
+  rm -f "$dir/$3" ;;
+esac
+`
+
+func TestCommandStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake pass command requires a posix shell")
+	}
+
+	d, err := ioutil.TempDir("", "command-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	cmd := filepath.Join(d, "pass")
+	if err := ioutil.WriteFile(cmd, []byte(fakePass), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("FAKE_PASS_DIR", filepath.Join(d, "store"))
+	defer os.Unsetenv("FAKE_PASS_DIR")
+
+	s := NewCommandStore(cmd).WithPrefix("test/")
+	testSecretStore(t, s)
+
+	if _, err := os.Stat(filepath.Join(d, "store", "test", "aws", "two")); err != nil {
+		t.Error("prefix not used")
+	}
+
+	if err := s.Delete("missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-ini/ini"
+	"gopkg.in/yaml.v2"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// profileAttribute is the name of the serialized attribute holding the profile name
+const profileAttribute = "profile"
+
+// MarshalJSON serializes the AwsConfig as a single JSON object containing the profile name, the explicitly supported
+// attributes (using their INI attribute names), and all custom attributes
+func (c AwsConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.toMap())
+}
+
+// UnmarshalJSON populates the AwsConfig from a JSON object, as produced by MarshalJSON.  Attribute values must be
+// strings, numbers, or booleans, and numbers keep the text used in the document.
+func (c *AwsConfig) UnmarshalJSON(b []byte) error {
+	m := make(map[string]interface{})
+
+	// decode numbers as json.Number, so large values like account IDs aren't converted to floating point
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&m); err != nil {
+		return err
+	}
+
+	attrs := make(map[string]string, len(m))
+	for k, v := range m {
+		switch x := v.(type) {
+		case nil:
+			continue
+		case string:
+			attrs[k] = x
+		case json.Number:
+			attrs[k] = x.String()
+		case bool:
+			attrs[k] = strconv.FormatBool(x)
+		default:
+			return fmt.Errorf("unsupported value for attribute '%s': %v", k, v)
+		}
+	}
+	c.fromMap(attrs)
+	return nil
+}
+
+// MarshalYAML serializes the AwsConfig as a YAML mapping, using the same structure as MarshalJSON
+func (c AwsConfig) MarshalYAML() (interface{}, error) {
+	return c.toMap(), nil
+}
+
+// UnmarshalYAML populates the AwsConfig from a YAML mapping, as produced by MarshalYAML
+func (c *AwsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// decode the values as strings, so unquoted numbers like account IDs keep the text used in the document
+	m := make(map[string]string)
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+
+	c.fromMap(m)
+	return nil
+}
+
+// NewJsonConfigProvider initializes an IniConfigProvider from a JSON document containing a set of profiles.  The
+// document is an object keyed by profile name, where each value is an object in the format produced by
+// AwsConfig.MarshalJSON().  Valid sources are the same as NewIniConfigProvider, except a nil source is not supported.
+// The returned provider is not associated with the source file, so SaveTo() and WriteTo() output INI formatted data.
+func NewJsonConfigProvider(source interface{}, opts ...Option) (*IniConfigProvider, error) {
+	return newStructuredConfigProvider(source, json.Unmarshal, opts)
+}
+
+// NewYamlConfigProvider initializes an IniConfigProvider from a YAML document containing a set of profiles, using
+// the same structure as NewJsonConfigProvider
+func NewYamlConfigProvider(source interface{}, opts ...Option) (*IniConfigProvider, error) {
+	return newStructuredConfigProvider(source, yaml.Unmarshal, opts)
+}
+
+func newStructuredConfigProvider(source interface{}, unmarshal func([]byte, interface{}) error, opts []Option) (*IniConfigProvider, error) {
+	if source == nil {
+		return nil, fmt.Errorf("source is required")
+	}
+
+	f := new(awsConfigFile)
+	defer f.Close()
+
+	b, err := f.read(source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make(map[string]*AwsConfig)
+	if err := unmarshal(b, &profiles); err != nil {
+		return nil, err
+	}
+
+	data, err := profilesToIni(profiles)
+	if err != nil {
+		return nil, err
+	}
+	return NewIniConfigProvider(data, opts...)
+}
+
+// profilesToIni converts the set of profiles to AWS config file formatted data
+func profilesToIni(profiles map[string]*AwsConfig) ([]byte, error) {
+	names := make([]string, 0, len(profiles))
+	for k := range profiles {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	f := ini.Empty()
+	for _, n := range names {
+		sn := n
+		if n != DefaultProfileName {
+			sn = fmt.Sprintf("profile %s", n)
+		}
+
+		s, err := f.NewSection(sn)
+		if err != nil {
+			return nil, err
+		}
+
+		m := stringMap(profiles[n].toMap())
+		delete(m, profileAttribute)
+
+		for _, k := range sortedKeys(m) {
+			if _, err := s.NewKey(k, m[k]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	b := new(bytes.Buffer)
+	if _, err := f.WriteTo(b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// toMap returns the attributes of the AwsConfig, with the explicitly supported attributes overriding any raw
+// attribute values of the same name
+func (c *AwsConfig) toMap() map[string]interface{} {
+	m := make(map[string]interface{})
+	for k, v := range c.rawAttributes {
+		m[k] = v
+	}
+
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		n := t.Field(i).Tag.Get("ini")
+		if len(n) < 1 {
+			continue
+		}
+
+		if f := v.Field(i); !isZero(f) {
+			m[n] = f.Interface()
+		}
+	}
+
+	if len(c.Profile) > 0 {
+		m[profileAttribute] = c.Profile
+	}
+	return m
+}
+
+// fromMap replaces the attributes of the AwsConfig with the values in the map
+func (c *AwsConfig) fromMap(m map[string]string) {
+	*c = AwsConfig{rawAttributes: make(map[string]string)}
+
+	for k, v := range m {
+		if k == profileAttribute {
+			c.Profile = v
+		} else {
+			c.rawAttributes[k] = v
+		}
+	}
+
+	c.setFields()
+}
+
+// setFields sets the explicitly supported attributes from the raw attribute values, using the ini struct tags
+func (c *AwsConfig) setFields() {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		n := t.Field(i).Tag.Get("ini")
+		if len(n) < 1 {
+			continue
+		}
+
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(c.Get(n))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i, err := strconv.ParseInt(c.Get(n), 0, 64)
+			if err != nil {
+				i = 0
+			}
+			f.SetInt(i)
+		}
+	}
+}
+
+func isZero(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+func stringMap(m map[string]interface{}) map[string]string {
+	s := make(map[string]string, len(m))
+	for k, v := range m {
+		s[k] = fmt.Sprint(v)
+	}
+	return s
+}
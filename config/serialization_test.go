@@ -0,0 +1,181 @@
+package config
+
+import (
+	"encoding/json"
+	"gopkg.in/yaml.v2"
+	"testing"
+)
+
+func TestAwsConfig_MarshalJSON(t *testing.T) {
+	p, err := NewIniConfigProvider(ConfFileName)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer p.Close()
+
+	c, err := p.Config("other")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	c.DurationSeconds = 3600
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if string(b) != `{"custom_attribute":"whatIsIt","duration_seconds":3600,"profile":"other","region":"us-west-1"}` {
+		t.Errorf("unexpected output: %s", b)
+		return
+	}
+
+	if v, err := json.Marshal(*c); err != nil || string(v) != string(b) {
+		t.Errorf("unexpected output marshalling value: %s", v)
+	}
+
+	n := new(AwsConfig)
+	if err := json.Unmarshal(b, n); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if n.Profile != "other" || n.Region != "us-west-1" || n.DurationSeconds != 3600 || n.Get("custom_attribute") != "whatIsIt" {
+		t.Errorf("data mismatch: %+v", n)
+	}
+}
+
+func TestAwsConfig_UnmarshalJSON(t *testing.T) {
+	t.Run("numbers", func(t *testing.T) {
+		c := new(AwsConfig)
+		if err := json.Unmarshal([]byte(`{"account_id": 123456789012, "duration_seconds": "900"}`), c); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Get("account_id") != "123456789012" || c.DurationSeconds != 900 {
+			t.Errorf("data mismatch: %+v", c)
+		}
+	})
+
+	t.Run("leading zeros", func(t *testing.T) {
+		c := new(AwsConfig)
+		if err := json.Unmarshal([]byte(`{"account_id": "012345678901", "big": 1e400, "flag": true}`), c); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Get("account_id") != "012345678901" || c.Get("big") != "1e400" || c.Get("flag") != "true" {
+			t.Errorf("data mismatch: %+v", c.Attributes())
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		if err := json.Unmarshal([]byte(`{"region": {"name": "us-east-1"}}`), new(AwsConfig)); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestAwsConfig_MarshalYAML(t *testing.T) {
+	c := &AwsConfig{Profile: "p", RoleArn: "arn:aws:iam::123456789012:role/Admin", rawAttributes: map[string]string{"x": "y"}}
+
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if v, err := yaml.Marshal(*c); err != nil || string(v) != string(b) {
+		t.Errorf("unexpected output marshalling value: %s", v)
+	}
+
+	n := new(AwsConfig)
+	if err := yaml.Unmarshal(b, n); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if n.Profile != "p" || n.RoleArn != c.RoleArn || n.Get("role_arn") != c.RoleArn || n.Get("x") != "y" {
+		t.Errorf("data mismatch: %+v", n)
+	}
+}
+
+func TestAwsConfig_UnmarshalYAML(t *testing.T) {
+	t.Run("numbers", func(t *testing.T) {
+		c := new(AwsConfig)
+		if err := yaml.Unmarshal([]byte("account_id: 012345678901\nother_id: 001234567012\nduration_seconds: 900\n"), c); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Get("account_id") != "012345678901" || c.Get("other_id") != "001234567012" || c.DurationSeconds != 900 {
+			t.Errorf("data mismatch: %+v", c.Attributes())
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		if err := yaml.Unmarshal([]byte("region:\n  name: us-east-1\n"), new(AwsConfig)); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestNewJsonConfigProvider(t *testing.T) {
+	t.Run("good", func(t *testing.T) {
+		p, err := NewJsonConfigProvider([]byte(`{
+			"default": {"region": "us-east-1"},
+			"admin": {"role_arn": "arn:aws:iam::123456789012:role/Admin", "source_profile": "default", "duration_seconds": 7200}
+		}`))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		c, err := p.Config("admin")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if c.Profile != "admin" || c.DurationSeconds != 7200 || c.SourceProfile != DefaultProfileName {
+			t.Errorf("data mismatch: %+v", c)
+		}
+
+		if s, _ := p.Profile("admin"); s.Name() != "profile admin" {
+			t.Error("bad section name")
+		}
+	})
+
+	t.Run("bad json", func(t *testing.T) {
+		if _, err := NewJsonConfigProvider([]byte(`[default]`)); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+
+	t.Run("nil source", func(t *testing.T) {
+		if _, err := NewJsonConfigProvider(nil); err == nil {
+			t.Error("did not receive expected error")
+		}
+	})
+}
+
+func TestNewYamlConfigProvider(t *testing.T) {
+	p, err := NewYamlConfigProvider([]byte("default:\n  region: us-east-1\nother:\n  region: us-west-2\n  custom: 1\n"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	c, err := p.Config("other")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if c.Region != "us-west-2" || c.Get("custom") != "1" {
+		t.Errorf("data mismatch: %+v", c)
+	}
+}
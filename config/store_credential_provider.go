@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"strings"
+)
+
+// SecretRefAttribute is the credentials file attribute holding the SecretStore key of a profile's secret access key.
+// If the profile has session credentials, the session token is stored using the key with a "/aws_session_token" suffix.
+const SecretRefAttribute = "x_secret_ref"
+
+// SessionTokenRefAttribute is the credentials file attribute set when the session token of the profile is kept in
+// the SecretStore
+const SessionTokenRefAttribute = "x_session_token_ref"
+
+const sessionTokenRefSuffix = "/aws_session_token"
+
+// StoreCredentialProvider enables the lookup of AWS credentials where the access key (and other non-secret attributes)
+// are kept in an ini-formatted credentials file, and the secret key and session token are kept in a SecretStore.  The
+// profile references the secret using the x_secret_ref attribute.  Profiles without the attribute are read from the
+// credentials file as plaintext, so a file can be migrated incrementally.
+//
+// Updates to the credentials file are only made to the in-memory representation of the data, it is the caller's
+// responsibility to persist the information using the SaveTo() or WriteTo() methods of the IniCredentialProvider.
+type StoreCredentialProvider struct {
+	file  *IniCredentialProvider
+	store SecretStore
+}
+
+// NewStoreCredentialProvider creates a StoreCredentialProvider using the credentials file and SecretStore
+func NewStoreCredentialProvider(file *IniCredentialProvider, store SecretStore) *StoreCredentialProvider {
+	return &StoreCredentialProvider{file: file, store: store}
+}
+
+// Credentials will retrieve the AWS credentials for the provided profile, getting the secrets from the SecretStore if
+// the profile has a secret reference.  Profile name resolution is the same as IniCredentialProvider.Credentials()
+func (p *StoreCredentialProvider) Credentials(profile ...string) (credentials.Value, error) {
+	p.file.mu.RLock()
+	s, err := p.file.profile(firstOrEmpty(profile), nil)
+	if err != nil {
+		p.file.mu.RUnlock()
+		return credentials.Value{}, err
+	}
+	name, attrs := s.Name(), s.KeysHash()
+	p.file.mu.RUnlock()
+
+	ref, ok := attrs[SecretRefAttribute]
+	if !ok {
+		return p.file.Credentials(profile...)
+	}
+
+	v := credentials.Value{AccessKeyID: attrs["aws_access_key_id"]}
+	if v.SecretAccessKey, err = p.store.Get(ref); err != nil {
+		return credentials.Value{}, err
+	}
+
+	if _, ok := attrs[SessionTokenRefAttribute]; ok {
+		if v.SessionToken, err = p.store.Get(ref + sessionTokenRefSuffix); err != nil {
+			return credentials.Value{}, err
+		}
+	}
+
+	if !v.HasKeys() {
+		return v, &IncompleteCredentialsError{Profile: name}
+	}
+	return v, nil
+}
+
+// UpdateCredentials updates the given profile with the provided credentials (using the same types as
+// IniCredentialProvider.UpdateCredentials()), storing the secret key and session token in the SecretStore.  The
+// secret reference is the existing x_secret_ref value of the profile, or the name of the profile section (after
+// resolving the profile name using the ProfilePolicy), and any plaintext secrets in the profile are removed.  If creds
+// is nil, the existing plaintext credentials of the profile are moved to the SecretStore, which migrates the profile.
+func (p *StoreCredentialProvider) UpdateCredentials(profile string, creds interface{}) error {
+	if creds == nil {
+		v, err := p.file.Credentials(profile)
+		if err != nil {
+			return err
+		}
+		creds = v
+	}
+
+	c, err := newAwsCredentials(creds)
+	if err != nil || c == nil {
+		return err
+	}
+
+	p.file.mu.RLock()
+	s, err := p.file.profile(profile, nil)
+	if err != nil {
+		p.file.mu.RUnlock()
+		return err
+	}
+	name, attrs := s.Name(), s.KeysHash()
+	p.file.mu.RUnlock()
+
+	ref := attrs[SecretRefAttribute]
+	_, hasToken := attrs[SessionTokenRefAttribute]
+	if len(ref) < 1 {
+		ref = name
+	}
+
+	if err := p.store.Set(ref, c.SecretKey); err != nil {
+		return err
+	}
+
+	if len(c.SessionToken) > 0 {
+		if err := p.store.Set(ref+sessionTokenRefSuffix, c.SessionToken); err != nil {
+			return err
+		}
+	} else if hasToken {
+		if err := p.store.Delete(ref + sessionTokenRefSuffix); err != nil && !errors.Is(err, ErrSecretNotFound) {
+			return err
+		}
+	}
+
+	p.file.mu.Lock()
+	defer p.file.mu.Unlock()
+
+	// the section may have been removed while the lock was released to update the store
+	if s, err = p.file.GetSection(name); err != nil {
+		return &ProfileNotFoundError{Profile: name}
+	}
+
+	oldKey := s.KeysHash()["aws_access_key_id"]
+	s.Key("aws_access_key_id").SetValue(c.AccessKey)
+	s.Key(SecretRefAttribute).SetValue(ref)
+	s.DeleteKey("aws_secret_access_key")
+	s.DeleteKey("aws_session_token")
+
+	if len(c.SessionToken) > 0 {
+		s.Key(SessionTokenRefAttribute).SetValue("true")
+	} else {
+		s.DeleteKey(SessionTokenRefAttribute)
+	}
+
+	if p.file.keyCreated {
+		setKeyCreated(s, oldKey, c, creds)
+	}
+	return nil
+}
+
+// Migrate moves the plaintext secrets of the named profiles in the credentials file to the SecretStore.  If no
+// profiles are provided, all profiles with a plaintext secret key are migrated.  The names of the migrated profiles
+// are returned.
+func (p *StoreCredentialProvider) Migrate(profiles ...string) ([]string, error) {
+	if len(profiles) < 1 {
+		p.file.mu.RLock()
+		for _, s := range p.file.Sections() {
+			if p.file.isProfileSection(s.Name()) && s.HasKey("aws_secret_access_key") {
+				profiles = append(profiles, s.Name())
+			}
+		}
+		p.file.mu.RUnlock()
+	}
+
+	migrated := make([]string, 0, len(profiles))
+	for _, n := range profiles {
+		if err := p.UpdateCredentials(n, nil); err != nil {
+			return migrated, err
+		}
+		migrated = append(migrated, strings.TrimPrefix(n, "profile "))
+	}
+	return migrated, nil
+}
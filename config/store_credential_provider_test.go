@@ -0,0 +1,160 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"strings"
+	"testing"
+)
+
+var storeCreds = []byte(`[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = defaultsecret
+
+[stored]
+aws_access_key_id = AKIASTORED
+x_secret_ref = aws/stored
+
+[session]
+aws_access_key_id = ASIASESSION
+aws_secret_access_key = sessionsecret
+aws_session_token = sessiontoken
+
+[missing]
+aws_access_key_id = AKIAMISSING
+x_secret_ref = aws/missing
+`)
+
+func newTestStoreProvider(t *testing.T) (*StoreCredentialProvider, *IniCredentialProvider, mapStore) {
+	f, err := NewIniCredentialProvider(storeCreds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := mapStore{"aws/stored": "storedsecret"}
+	return NewStoreCredentialProvider(f, s), f, s
+}
+
+func TestStoreCredentialProvider_Credentials(t *testing.T) {
+	p, _, _ := newTestStoreProvider(t)
+
+	t.Run("stored", func(t *testing.T) {
+		v, err := p.Credentials("stored")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if v.AccessKeyID != "AKIASTORED" || v.SecretAccessKey != "storedsecret" {
+			t.Errorf("data mismatch: %+v", v)
+		}
+	})
+
+	t.Run("plaintext", func(t *testing.T) {
+		v, err := p.Credentials()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if v.AccessKeyID != "AKIADEFAULT" || v.SecretAccessKey != "defaultsecret" {
+			t.Errorf("data mismatch: %+v", v)
+		}
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		if _, err := p.Credentials("missing"); !errors.Is(err, ErrSecretNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("missing profile", func(t *testing.T) {
+		if _, err := p.Credentials("nope"); !errors.Is(err, ErrProfileNotFound) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestStoreCredentialProvider_UpdateCredentials(t *testing.T) {
+	p, f, s := newTestStoreProvider(t)
+
+	t.Run("update", func(t *testing.T) {
+		v := credentials.Value{AccessKeyID: "AKIANEW", SecretAccessKey: "newsecret", SessionToken: "newtoken"}
+		if err := p.UpdateCredentials("stored", v); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if s["aws/stored"] != "newsecret" || s["aws/stored/aws_session_token"] != "newtoken" {
+			t.Errorf("store not updated: %v", s)
+		}
+
+		c, err := p.Credentials("stored")
+		if err != nil || c.AccessKeyID != "AKIANEW" || c.SessionToken != "newtoken" {
+			t.Errorf("data mismatch: %+v, %v", c, err)
+		}
+
+		v.SessionToken = ""
+		if err := p.UpdateCredentials("stored", v); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if _, ok := s["aws/stored/aws_session_token"]; ok {
+			t.Error("session token not removed from store")
+		}
+	})
+
+	t.Run("policy profile", func(t *testing.T) {
+		f, err := NewIniCredentialProvider(storeCreds, WithEnvironment(MapEnvironment{ProfileEnvVar: "session"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := mapStore{}
+		v := credentials.Value{AccessKeyID: "ASIANEW", SecretAccessKey: "newsecret"}
+		if err := NewStoreCredentialProvider(f, s).UpdateCredentials("", v); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := s[""]; ok || s["session"] != "newsecret" {
+			t.Errorf("unexpected store keys: %v", s)
+		}
+
+		if r, _ := f.Profile("session"); r.KeysHash()[SecretRefAttribute] != "session" {
+			t.Errorf("unexpected secret reference: %v", r.KeysHash())
+		}
+	})
+
+	t.Run("migrate", func(t *testing.T) {
+		m, err := p.Migrate()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if strings.Join(m, ",") != "default,session" {
+			t.Errorf("unexpected migrated profiles: %v", m)
+		}
+
+		b := new(bytes.Buffer)
+		if _, err := f.WriteTo(b); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if strings.Contains(b.String(), "aws_secret_access_key") || strings.Contains(b.String(), "aws_session_token") {
+			t.Errorf("plaintext secrets in credentials file:\n%s", b.String())
+		}
+
+		c, err := p.Credentials("session")
+		if err != nil || c.SecretAccessKey != "sessionsecret" || c.SessionToken != "sessiontoken" {
+			t.Errorf("data mismatch: %+v, %v", c, err)
+		}
+
+		if len(f.Lint()) > 0 {
+			t.Errorf("unexpected lint diagnostics: %v", f.Lint())
+		}
+	})
+}
@@ -15,6 +15,7 @@ type AwsConfig struct {
 	RoleSessionName  string `ini:"role_session_name" env:"AWS_ROLE_SESSION_NAME"`
 	SourceProfile    string `ini:"source_profile"`
 	rawAttributes    map[string]string
+	sources          map[string]string
 }
 
 // Get will return the value of the INI config attribute name specified in attr
@@ -23,6 +24,29 @@ func (c *AwsConfig) Get(attr string) string {
 	return c.rawAttributes[attr]
 }
 
+// Attributes will return a copy of all INI config attributes and their values
+func (c *AwsConfig) Attributes() map[string]string {
+	m := make(map[string]string, len(c.rawAttributes))
+	for k, v := range c.rawAttributes {
+		m[k] = v
+	}
+	return m
+}
+
+// Source will return the name of the profile which provided the value of the INI config attribute name specified in attr,
+// or an empty string if the attribute is not set.  For configuration built by a resolver, this will be the profile
+// the value was merged from (default, source_profile, or the requested profile)
+func (c *AwsConfig) Source(attr string) string {
+	if s, ok := c.sources[attr]; ok {
+		return s
+	}
+
+	if _, ok := c.rawAttributes[attr]; ok {
+		return c.Profile
+	}
+	return ""
+}
+
 type awsCredentials struct {
 	AccessKey    string `ini:"aws_access_key_id" env:"AWS_ACCESS_KEY_ID,AWS_ACCESS_KEY"`
 	SecretKey    string `ini:"aws_secret_access_key" env:"AWS_SECRET_ACCESS_KEY,AWS_SECRET_KEY"`
@@ -35,6 +59,11 @@ type AwsConfigProvider interface {
 	ListProfiles(bool) []string
 }
 
+// ProfileQuerier is an interface defining the contract for conforming types to provide filtered profile metadata
+type ProfileQuerier interface {
+	QueryProfiles(q *ProfileQuery) []ProfileEntry
+}
+
 // AwsCredentialProvider is an interface defining the contract for conforming types to provide AWS credentials
 type AwsCredentialProvider interface {
 	Credentials(profile ...string) (credentials.Value, error)
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"regexp"
+	"strings"
+)
+
+const (
+	minDurationSeconds = 900
+	maxDurationSeconds = 43200
+)
+
+var (
+	accountIdRe = regexp.MustCompile(`^\d{12}$`)
+	mfaSerialRe = regexp.MustCompile(`^[\w+=/:,.@-]{9,256}$`)
+)
+
+// FieldError is a validation failure for a single configuration attribute
+type FieldError struct {
+	Attribute string
+	Value     string
+	Message   string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: invalid value '%s': %s", e.Attribute, e.Value, e.Message)
+}
+
+// ValidationError is the collection of attribute validation failures found by AwsConfig.Validate()
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	s := make([]string, len(e.Errors))
+	for i, v := range e.Errors {
+		s[i] = v.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Validate checks the format of the RoleArn, MfaSerial, Region and DurationSeconds attributes.  ARNs must be well-formed
+// IAM ARNs in a known partition, the region must be found in the AWS SDK partition/region table (and in the same partition
+// as the role), MFA serials must be an IAM mfa ARN or a hardware device serial number, and DurationSeconds must be between
+// 900 and 43200 seconds.  Empty attributes are not validated.  If any attribute is invalid, a *ValidationError is returned.
+func (c *AwsConfig) Validate() error {
+	e := new(ValidationError)
+	add := func(attr, val string, err error) {
+		if err != nil {
+			e.Errors = append(e.Errors, &FieldError{Attribute: attr, Value: val, Message: err.Error()})
+		}
+	}
+
+	if len(c.RoleArn) > 0 {
+		add("role_arn", c.RoleArn, validateRoleArn(c.RoleArn))
+	}
+
+	if len(c.MfaSerial) > 0 {
+		add("mfa_serial", c.MfaSerial, validateMfaSerial(c.MfaSerial))
+	}
+
+	if len(c.Region) > 0 {
+		err := validateRegion(c.Region)
+		if err == nil && len(c.RoleArn) > 0 {
+			if a, aErr := arn.Parse(c.RoleArn); aErr == nil {
+				if p, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), c.Region); ok && p.ID() != a.Partition {
+					err = fmt.Errorf("region is in partition %s, role_arn is in partition %s", p.ID(), a.Partition)
+				}
+			}
+		}
+		add("region", c.Region, err)
+	}
+
+	if c.DurationSeconds != 0 {
+		add("duration_seconds", fmt.Sprintf("%d", c.DurationSeconds), validateDuration(c.DurationSeconds))
+	}
+
+	if len(e.Errors) > 0 {
+		return e
+	}
+	return nil
+}
+
+// validateArn verifies the value is a well-formed ARN in a known partition, for the given service, with a resource
+// starting with the given prefix.  The account, if required, must be a 12 digit AWS account ID.
+func validateArn(v, service, resourcePrefix string, requireAccount bool) error {
+	a, err := arn.Parse(v)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := partition(a.Partition); !ok {
+		return fmt.Errorf("unknown partition '%s'", a.Partition)
+	}
+
+	if a.Service != service {
+		return fmt.Errorf("expected service '%s', found '%s'", service, a.Service)
+	}
+
+	if requireAccount && !accountIdRe.MatchString(a.AccountID) {
+		return fmt.Errorf("invalid account ID '%s'", a.AccountID)
+	}
+
+	if !strings.HasPrefix(a.Resource, resourcePrefix) || len(a.Resource) <= len(resourcePrefix) {
+		return fmt.Errorf("expected %s resource, found '%s'", strings.TrimSuffix(resourcePrefix, "/"), a.Resource)
+	}
+	return nil
+}
+
+func validateRoleArn(v string) error {
+	return validateArn(v, "iam", "role/", true)
+}
+
+// validateMfaSerial allows either an IAM mfa device ARN, or the serial number of a hardware device
+func validateMfaSerial(v string) error {
+	if strings.HasPrefix(v, "arn:") {
+		return validateArn(v, "iam", "mfa/", true)
+	}
+
+	if !mfaSerialRe.MatchString(v) {
+		return fmt.Errorf("not an mfa ARN or hardware device serial number")
+	}
+	return nil
+}
+
+func validateRegion(v string) error {
+	for _, p := range endpoints.DefaultPartitions() {
+		if _, ok := p.Regions()[v]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown region")
+}
+
+func validateDuration(v int) error {
+	if v < minDurationSeconds || v > maxDurationSeconds {
+		return fmt.Errorf("out of range [%d, %d]", minDurationSeconds, maxDurationSeconds)
+	}
+	return nil
+}
+
+func partition(id string) (endpoints.Partition, bool) {
+	for _, p := range endpoints.DefaultPartitions() {
+		if p.ID() == id {
+			return p, true
+		}
+	}
+	return endpoints.Partition{}, false
+}
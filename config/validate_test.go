@@ -0,0 +1,71 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAwsConfig_Validate(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		if err := new(AwsConfig).Validate(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("good", func(t *testing.T) {
+		c := AwsConfig{
+			RoleArn:         "arn:aws:iam::123456789012:role/path/Admin",
+			MfaSerial:       "arn:aws:iam::123456789012:mfa/user",
+			Region:          "us-east-2",
+			DurationSeconds: 43200,
+		}
+
+		if err := c.Validate(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("hardware mfa", func(t *testing.T) {
+		c := AwsConfig{MfaSerial: "GAHT12345678"}
+		if err := c.Validate(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("gov cloud", func(t *testing.T) {
+		c := AwsConfig{RoleArn: "arn:aws-us-gov:iam::123456789012:role/Admin", Region: "us-gov-west-1"}
+		if err := c.Validate(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	bad := map[string]AwsConfig{
+		"fixture mfa":        {MfaSerial: "arn:aws::iam:mfa/12345"},
+		"short mfa":          {MfaSerial: "12345"},
+		"mfa resource":       {MfaSerial: "arn:aws:iam::123456789012:user/me"},
+		"role service":       {RoleArn: "arn:aws:sts::123456789012:role/Admin"},
+		"role partition":     {RoleArn: "arn:aws-mars:iam::123456789012:role/Admin"},
+		"role account":       {RoleArn: "arn:aws:iam::1234:role/Admin"},
+		"role resource":      {RoleArn: "arn:aws:iam::123456789012:role/"},
+		"region":             {Region: "us-mars-1"},
+		"partition mismatch": {RoleArn: "arn:aws-cn:iam::123456789012:role/Admin", Region: "us-east-1"},
+		"duration low":       {DurationSeconds: 899},
+		"duration high":      {DurationSeconds: 43201},
+	}
+
+	for k, v := range bad {
+		c := v
+		t.Run(k, func(t *testing.T) {
+			err := c.Validate()
+			if err == nil {
+				t.Error("did not receive expected error")
+				return
+			}
+
+			var e *ValidationError
+			if !errors.As(err, &e) || len(e.Errors) != 1 {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -4,7 +4,9 @@ require (
 	github.com/aws/aws-sdk-go v1.34.0
 	github.com/go-ini/ini v1.49.0
 	github.com/smartystreets/goconvey v1.7.2 // indirect
+	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad
 	gopkg.in/ini.v1 v1.67.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 go 1.13